@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"gee-demo/gee"
+	"net/http"
+	"strings"
+)
+
+/* ---------------------------------- API Key 认证 ---------------------------------- */
+
+type APIKeyConfig struct {
+	// 读取 key 的位置，按顺序查找，格式为 "header:X-API-Key"、"query:api_key"、"cookie:api_key"
+	// 默认 header:X-API-Key
+	Lookups []string
+	// 静态的 key -> 身份 映射
+	Keys map[string]string
+	// 自定义校验，返回 key 对应的身份，优先于 Keys
+	Validator func(ctx *gee.Context, key string) (string, bool)
+}
+
+// API Key 认证，认证通过后身份存入 UserKey
+func APIKey(config APIKeyConfig) gee.HandlerFunc {
+	if len(config.Lookups) == 0 {
+		config.Lookups = []string{"header:X-API-Key"}
+	}
+	extractors := newExtractors(config.Lookups)
+
+	if config.Validator == nil {
+		keys := make(map[[sha256.Size]byte]string, len(config.Keys))
+		for key, identity := range config.Keys {
+			keys[sha256.Sum256([]byte(key))] = identity
+		}
+		config.Validator = func(ctx *gee.Context, key string) (string, bool) {
+			sum := sha256.Sum256([]byte(key))
+			identity, found := "", false
+			for expect, id := range keys {
+				if subtle.ConstantTimeCompare(sum[:], expect[:]) == 1 {
+					identity, found = id, true
+				}
+			}
+			return identity, found
+		}
+	}
+
+	return func(ctx *gee.Context) {
+		key := extract(ctx, extractors)
+		if key == "" {
+			ctx.FatalT(http.StatusUnauthorized, "auth.missing_api_key")
+			return
+		}
+
+		identity, ok := config.Validator(ctx, key)
+		if !ok {
+			ctx.FatalT(http.StatusUnauthorized, "auth.invalid_api_key")
+			return
+		}
+
+		ctx.Set(UserKey, identity)
+		ctx.Next()
+	}
+}
+
+/* ---------------------------------- 凭证提取 ---------------------------------- */
+
+type extractor func(ctx *gee.Context) string
+
+// 解析 "header:Authorization"、"query:token"、"cookie:token"
+// header 的值可以带前缀，如 "header:Authorization:Bearer "
+func newExtractors(lookups []string) []extractor {
+	extractors := make([]extractor, 0, len(lookups))
+
+	for _, lookup := range lookups {
+		parts := strings.SplitN(lookup, ":", 3)
+		if len(parts) < 2 {
+			panic("auth: invalid lookup " + lookup)
+		}
+		name := strings.TrimSpace(parts[1])
+
+		switch strings.TrimSpace(parts[0]) {
+		case "header":
+			prefix := ""
+			if len(parts) == 3 {
+				prefix = parts[2]
+			}
+			extractors = append(extractors, func(ctx *gee.Context) string {
+				value := ctx.Req.Header.Get(name)
+				if prefix == "" {
+					return value
+				}
+				value, _ = cutPrefixFold(value, prefix)
+				return value
+			})
+		case "query":
+			extractors = append(extractors, func(ctx *gee.Context) string {
+				return ctx.Query(name)
+			})
+		case "cookie":
+			extractors = append(extractors, func(ctx *gee.Context) string {
+				value, _ := ctx.Cookie(name)
+				return value
+			})
+		default:
+			panic("auth: invalid lookup " + lookup)
+		}
+	}
+
+	return extractors
+}
+
+func extract(ctx *gee.Context, extractors []extractor) string {
+	for _, fn := range extractors {
+		if value := strings.TrimSpace(fn(ctx)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
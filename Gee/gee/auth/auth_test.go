@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEngine(middleware gee.HandlerFunc) *gee.Engine {
+	engine := gee.New()
+	engine.Use(middleware)
+	engine.Get("/me", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, ctx.GetString(UserKey))
+	})
+	return engine
+}
+
+func serve(engine *gee.Engine, req *http.Request) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func TestBasicAuth(t *testing.T) {
+	engine := newEngine(BasicAuthForRealm(Accounts{"tom": "123"}, "admin"))
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.SetBasicAuth("tom", "123")
+	if res := serve(engine, req); res.Code != http.StatusOK || res.Body.String() != "tom" {
+		t.Fatalf("valid credentials should pass, got %d %q", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.SetBasicAuth("tom", "456")
+	res := serve(engine, req)
+	if res.Code != http.StatusUnauthorized || res.Header().Get("WWW-Authenticate") != `Basic realm="admin", charset="UTF-8"` {
+		t.Fatalf("invalid credentials should be rejected with realm, got %d %v", res.Code, res.Header())
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	engine := newEngine(APIKey(APIKeyConfig{
+		Lookups: []string{"header:X-API-Key", "query:api_key", "cookie:api_key"},
+		Keys:    map[string]string{"secret-1": "service-a"},
+	}))
+
+	header := httptest.NewRequest(http.MethodGet, "/me", nil)
+	header.Header.Set("X-API-Key", "secret-1")
+	query := httptest.NewRequest(http.MethodGet, "/me?api_key=secret-1", nil)
+	cookie := httptest.NewRequest(http.MethodGet, "/me", nil)
+	cookie.AddCookie(&http.Cookie{Name: "api_key", Value: "secret-1"})
+
+	for _, req := range []*http.Request{header, query, cookie} {
+		if res := serve(engine, req); res.Code != http.StatusOK || res.Body.String() != "service-a" {
+			t.Fatalf("valid api key should pass, got %d", res.Code)
+		}
+	}
+
+	if res := serve(engine, httptest.NewRequest(http.MethodGet, "/me?api_key=wrong", nil)); res.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid api key should be rejected, got %d", res.Code)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("hs256-secret")
+
+	keys := []struct {
+		sign   interface{}
+		verify interface{}
+	}{
+		{secret, secret},
+		{rsaKey, &rsaKey.PublicKey},
+		{ecKey, &ecKey.PublicKey},
+	}
+
+	now := time.Now().Unix()
+	for _, key := range keys {
+		engine := newEngine(JWT(JWTConfig{Key: key.verify, Audience: "gee", Issuer: "auth"}))
+
+		token, err := SignJWT(Claims{"sub": "tom", "aud": []string{"gee"}, "iss": "auth", "exp": now + 60}, key.sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if res := serve(engine, req); res.Code != http.StatusOK || res.Body.String() != "tom" {
+			t.Fatalf("valid token should pass, got %d %q", res.Code, res.Body.String())
+		}
+	}
+}
+
+func TestJWTInvalid(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	secret := []byte("hs256-secret")
+	config := JWTConfig{Key: &rsaKey.PublicKey, Audience: "gee"}
+	now := time.Now().Unix()
+
+	sign := func(claims Claims, key interface{}) string {
+		token, _ := SignJWT(claims, key)
+		return token
+	}
+	valid := sign(Claims{"aud": "gee", "exp": now + 60}, rsaKey)
+	tampered := strings.Split(valid, ".")
+	tampered[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"aud":"gee","exp":9999999999,"admin":true}`))
+
+	cases := map[string]error{
+		sign(Claims{"aud": "gee", "exp": now - 60}, rsaKey):   ErrTokenExpired,
+		sign(Claims{"aud": "gee", "nbf": now + 60}, rsaKey):   ErrTokenNotValidYet,
+		sign(Claims{"aud": "other", "exp": now + 60}, rsaKey): ErrTokenAudience,
+		sign(Claims{"aud": "gee", "exp": now + 60}, secret):   ErrTokenAlgorithm,
+		strings.Join(tampered, "."):                           ErrTokenSignature,
+		"not-a-token":                                         ErrTokenMalformed,
+	}
+	for token, expect := range cases {
+		if _, err := config.Parse(token); err != expect {
+			t.Fatalf("expect %v, got %v", expect, err)
+		}
+	}
+
+	// 允许时钟偏差
+	config.Leeway = 2 * time.Minute
+	if _, err := config.Parse(sign(Claims{"aud": "gee", "exp": now - 60}, rsaKey)); err != nil {
+		t.Fatalf("token within leeway should pass, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"gee-demo/gee"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/* ---------------------------------- Basic 认证 ---------------------------------- */
+
+// 认证通过的用户名在 ctx 键值存储中的key
+const UserKey = "gee.auth.user"
+
+// 用户名 -> 密码
+type Accounts map[string]string
+
+// Basic 认证，realm 默认为 "Authorization Required"
+func BasicAuth(accounts Accounts) gee.HandlerFunc {
+	return BasicAuthForRealm(accounts, "")
+}
+
+func BasicAuthForRealm(accounts Accounts, realm string) gee.HandlerFunc {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm) + `, charset="UTF-8"`
+
+	// 预先计算所有凭证的摘要，比较摘要可以避免长度不同导致的时间差异
+	credentials := make(map[string][sha256.Size]byte, len(accounts))
+	for user, password := range accounts {
+		credentials[user] = sha256.Sum256([]byte(user + ":" + password))
+	}
+
+	return func(ctx *gee.Context) {
+		user, ok := checkBasic(ctx.Req.Header.Get("Authorization"), credentials)
+		if !ok {
+			ctx.SetHeader("WWW-Authenticate", challenge)
+			ctx.FatalT(http.StatusUnauthorized, "gee.unauthorized")
+			return
+		}
+
+		ctx.Set(UserKey, user)
+		ctx.Next()
+	}
+}
+
+func checkBasic(header string, credentials map[string][sha256.Size]byte) (string, bool) {
+	encoded, ok := cutPrefixFold(header, "Basic ")
+	if !ok {
+		return "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", false
+	}
+
+	user, _, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", false
+	}
+	sum := sha256.Sum256(decoded)
+
+	// 遍历所有凭证并比较，不因用户名是否存在而提前返回
+	found := false
+	for _, expect := range credentials {
+		if subtle.ConstantTimeCompare(sum[:], expect[:]) == 1 {
+			found = true
+		}
+	}
+	return user, found
+}
+
+// 大小写不敏感的前缀匹配，如 "Bearer "、"bearer "
+func cutPrefixFold(s string, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return "", false
+	}
+	return s[len(prefix):], true
+}
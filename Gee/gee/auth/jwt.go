@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gee-demo/gee"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/* ---------------------------------- JWT 认证 ---------------------------------- */
+/**
+ * JWT = base64url(header).base64url(claims).base64url(signature)
+ * 支持 HS256(HMAC-SHA256)、RS256(RSASSA-PKCS1-v1_5 + SHA256)、ES256(ECDSA P-256 + SHA256)
+ * 签名算法由密钥类型决定，不信任 header 中的 alg，避免算法混淆攻击
+ */
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+
+	// 认证通过的 claims 在 ctx 键值存储中的key
+	ClaimsKey = "gee.auth.claims"
+)
+
+var (
+	ErrTokenMalformed   = errors.New("jwt: malformed token")
+	ErrTokenSignature   = errors.New("jwt: invalid signature")
+	ErrTokenAlgorithm   = errors.New("jwt: unexpected signing algorithm")
+	ErrTokenExpired     = errors.New("jwt: token is expired")
+	ErrTokenNotValidYet = errors.New("jwt: token is not valid yet")
+	ErrTokenAudience    = errors.New("jwt: invalid audience")
+	ErrTokenIssuer      = errors.New("jwt: invalid issuer")
+)
+
+type Claims map[string]interface{}
+
+type JWTHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+type JWTConfig struct {
+	// 验证签名的密钥：HS256 为 []byte，RS256 为 *rsa.PublicKey，ES256 为 *ecdsa.PublicKey
+	Key interface{}
+	// 根据 header(如 kid) 选择密钥，优先于 Key，用于密钥轮换
+	KeyFunc func(header JWTHeader) (interface{}, error)
+	// 期望的 aud，为空时不校验
+	Audience string
+	// 期望的 iss，为空时不校验
+	Issuer string
+	// 校验 exp/nbf 时允许的时钟偏差
+	Leeway time.Duration
+	// 读取 token 的位置，默认 header:Authorization:Bearer
+	Lookups []string
+}
+
+// JWT 认证，认证通过后 claims 存入 ClaimsKey，sub 存入 UserKey
+func JWT(config JWTConfig) gee.HandlerFunc {
+	if config.Key == nil && config.KeyFunc == nil {
+		panic("auth: JWT requires Key or KeyFunc")
+	}
+	if len(config.Lookups) == 0 {
+		config.Lookups = []string{"header:Authorization:Bearer "}
+	}
+	extractors := newExtractors(config.Lookups)
+
+	return func(ctx *gee.Context) {
+		token := extract(ctx, extractors)
+		if token == "" {
+			ctx.SetHeader("WWW-Authenticate", "Bearer")
+			ctx.FatalT(http.StatusUnauthorized, "auth.missing_token")
+			return
+		}
+
+		claims, err := config.Parse(token)
+		if err != nil {
+			ctx.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+			ctx.FatalT(http.StatusUnauthorized, "auth.invalid_token", gee.H{"error": err.Error()})
+			return
+		}
+
+		ctx.Set(ClaimsKey, claims)
+		if sub, ok := claims["sub"].(string); ok {
+			ctx.Set(UserKey, sub)
+		}
+		ctx.Next()
+	}
+}
+
+// 获取当前请求的 claims
+func ClaimsFrom(ctx *gee.Context) Claims {
+	claims, _ := ctx.Get(ClaimsKey)
+	c, _ := claims.(Claims)
+	return c
+}
+
+// 校验签名与 exp/nbf/aud/iss，返回 claims
+func (config *JWTConfig) Parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header JWTHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	key := config.Key
+	if config.KeyFunc != nil {
+		var err error
+		if key, err = config.KeyFunc(header); err != nil {
+			return nil, err
+		}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := verify(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := config.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (config *JWTConfig) validate(claims Claims, now time.Time) error {
+	if exp, ok := claims.time("exp"); ok && !now.Before(exp.Add(config.Leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(config.Leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+	if config.Issuer != "" && claims["iss"] != config.Issuer {
+		return ErrTokenIssuer
+	}
+	if config.Audience != "" && !claims.hasAudience(config.Audience) {
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// NumericDate，单位为秒
+func (claims Claims) time(name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(value*float64(time.Second))), true
+}
+
+// aud 可以是字符串或字符串数组
+func (claims Claims) hasAudience(audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// 根据密钥类型校验签名，alg 必须与密钥类型一致
+func verify(alg string, key interface{}, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch k := key.(type) {
+	case []byte:
+		if alg != HS256 {
+			return ErrTokenAlgorithm
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+	case *rsa.PublicKey:
+		if alg != RS256 {
+			return ErrTokenAlgorithm
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return ErrTokenSignature
+		}
+	case *ecdsa.PublicKey:
+		if alg != ES256 || k.Curve != elliptic.P256() {
+			return ErrTokenAlgorithm
+		}
+		// 签名为定长的 r | s，各32字节
+		if len(signature) != 64 {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return ErrTokenAlgorithm
+	}
+
+	return nil
+}
+
+/* ---------------------------------- 签发 ---------------------------------- */
+
+// 签发 JWT，key 为 []byte(HS256)、*rsa.PrivateKey(RS256) 或 *ecdsa.PrivateKey(ES256)
+func SignJWT(claims Claims, key interface{}) (string, error) {
+	var alg string
+	switch key.(type) {
+	case []byte:
+		alg = HS256
+	case *rsa.PrivateKey:
+		alg = RS256
+	case *ecdsa.PrivateKey:
+		alg = ES256
+	default:
+		return "", ErrTokenAlgorithm
+	}
+
+	header, err := json.Marshal(JWTHeader{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			return "", err
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,347 @@
+package gee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/* ---------------------------------- 参数绑定 ---------------------------------- */
+/**
+ * 将请求参数绑定到结构体，并根据 binding 标签校验：
+ *  BindJSON   请求体 JSON，字段名使用 json 标签
+ *  BindQuery  Query 参数，字段名使用 form 标签
+ *  BindForm   表单参数，字段名使用 form 标签
+ *  Bind       根据请求方法与 Content-Type 自动选择
+ * 校验规则以逗号分隔，如 `binding:"required,min=1,max=20"`，支持：
+ *  required、min、max、len、oneof(以空格分隔)、email
+ * 解析失败返回 *HTTPError(400)，校验失败返回 ValidationErrors
+ */
+
+// 根据请求方法与 Content-Type 选择绑定方式
+func (ctx *Context) Bind(obj interface{}) error {
+	if ctx.Method == http.MethodGet || ctx.Method == http.MethodHead || ctx.Method == http.MethodDelete {
+		return ctx.BindQuery(obj)
+	}
+
+	contentType := ctx.Req.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		return ctx.BindJSON(obj)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"), strings.HasPrefix(contentType, "multipart/form-data"):
+		return ctx.BindForm(obj)
+	}
+	return NewHTTPError(http.StatusUnsupportedMediaType, "unsupported content type: "+contentType)
+}
+
+func (ctx *Context) BindJSON(obj interface{}) error {
+	if ctx.Req.Body == nil {
+		return NewHTTPError(http.StatusBadRequest, "empty request body")
+	}
+	if err := json.NewDecoder(ctx.Req.Body).Decode(obj); err != nil {
+		return NewHTTPError(http.StatusBadRequest, "invalid JSON body").Wrap(err)
+	}
+	return Validate(obj)
+}
+
+func (ctx *Context) BindQuery(obj interface{}) error {
+	if err := mapForm(obj, ctx.queryValues()); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+func (ctx *Context) BindForm(obj interface{}) error {
+	if ctx.formCache == nil {
+		if err := ctx.Req.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+			return NewHTTPError(http.StatusBadRequest, "invalid form body").Wrap(err)
+		}
+	}
+	if err := mapForm(obj, ctx.formValues()); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// 字段在请求中的名称，tag 为 "-" 时忽略该字段
+func FieldName(field reflect.StructField, tag string) (string, bool) {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// 将 url.Values 映射到结构体
+func mapForm(obj interface{}, values url.Values) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("gee: bind requires a pointer to struct, got %T", obj)
+	}
+	return mapFormValue(v.Elem(), values)
+}
+
+func mapFormValue(v reflect.Value, values url.Values) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		// 匿名嵌入的结构体，展开字段
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := mapFormValue(v.Field(i), values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := FieldName(field, "form")
+		if !ok {
+			continue
+		}
+		inputs, ok := values[name]
+		if !ok || len(inputs) == 0 {
+			continue
+		}
+		if err := setField(v.Field(i), inputs); err != nil {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid value for %s", name)).Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, inputs []string) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(inputs), len(inputs))
+		for i, input := range inputs {
+			if err := setValue(slice.Index(i), input); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setValue(field, inputs[0])
+}
+
+func setValue(v reflect.Value, input string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(input)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(input)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(input, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(input, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(input, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", v.Kind())
+	}
+	return nil
+}
+
+/* ---------------------------------- 校验 ---------------------------------- */
+
+// 根据 binding 标签校验结构体，字段名使用 json 标签
+func Validate(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	validateStruct(v, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// 校验规则
+type Rule struct {
+	Tag   string
+	Param string
+}
+
+// 解析 binding 标签
+func ParseRules(tag string) []Rule {
+	rules := make([]Rule, 0)
+	for _, r := range strings.Split(tag, ",") {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(r, "=")
+		rules = append(rules, Rule{Tag: name, Param: param})
+	}
+	return rules
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateStruct(fv, prefix, errs)
+			continue
+		}
+
+		name, ok := FieldName(field, "json")
+		if !ok {
+			continue
+		}
+		name = prefix + name
+
+		for _, rule := range ParseRules(field.Tag.Get("binding")) {
+			if key, ok := check(fv, rule); !ok {
+				fieldErr := FieldError{Field: name, Tag: rule.Tag, Param: rule.Param, key: key}
+				fieldErr.Message = FormatMessage(messages[key], fieldErr.args())
+				*errs = append(*errs, fieldErr)
+				// 每个字段只报告第一个错误
+				break
+			}
+		}
+
+		// 递归校验嵌套的结构体
+		elem := fv
+		for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Struct:
+			validateStruct(elem, name+".", errs)
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < elem.Len(); j++ {
+				item := elem.Index(j)
+				for item.Kind() == reflect.Ptr && !item.IsNil() {
+					item = item.Elem()
+				}
+				if item.Kind() == reflect.Struct {
+					validateStruct(item, fmt.Sprintf("%s[%d].", name, j), errs)
+				}
+			}
+		}
+	}
+}
+
+// 支持的校验规则
+var rules = map[string]bool{"required": true, "min": true, "max": true, "len": true, "oneof": true, "email": true}
+
+// 校验单个规则，返回错误信息的 key
+func check(v reflect.Value, rule Rule) (string, bool) {
+	// 未知的规则(如拼写错误)不能静默通过
+	if !rules[rule.Tag] {
+		return "validation.invalid_rule", false
+	}
+	// 空的指针只校验 required
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "validation.required", rule.Tag != "required"
+		}
+		v = v.Elem()
+	}
+
+	switch rule.Tag {
+	case "required":
+		return "validation.required", !v.IsZero()
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(rule.Param, 64)
+		if err != nil {
+			return "validation.invalid_rule", false
+		}
+		size, isLength := measure(v)
+		switch rule.Tag {
+		case "min":
+			if isLength {
+				return "validation.min_length", size >= limit
+			}
+			return "validation.min", size >= limit
+		case "max":
+			if isLength {
+				return "validation.max_length", size <= limit
+			}
+			return "validation.max", size <= limit
+		default:
+			return "validation.len", size == limit
+		}
+	case "oneof":
+		value := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(rule.Param) {
+			if value == option {
+				return "", true
+			}
+		}
+		return "validation.oneof", false
+	case "email":
+		s := v.String()
+		addr, err := mail.ParseAddress(s)
+		return "validation.email", s == "" || (err == nil && addr.Address == s)
+	}
+
+	return "validation.invalid_rule", false
+}
+
+// 数值返回其值，字符串/切片/map 返回长度
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,113 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type order struct {
+	Email string  `json:"email" binding:"required,email"`
+	Count int     `json:"count" binding:"min=1,max=10"`
+	State string  `json:"state" binding:"oneof=new paid"`
+	Code  string  `json:"code" binding:"len=4"`
+	Items []item  `json:"items" binding:"min=1"`
+	Note  *string `json:"note" binding:"max=3"`
+}
+
+type search struct {
+	Query string   `form:"q" binding:"required"`
+	Page  int      `form:"page"`
+	Tags  []string `form:"tag"`
+	Debug bool     `form:"debug"`
+}
+
+func TestValidate(t *testing.T) {
+	note := "toolong"
+	err := Validate(&order{Email: "a@", Count: 0, State: "gone", Code: "abc", Items: []item{{}}, Note: &note})
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect ValidationErrors, but got %v", err)
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field + ":" + e.Tag
+	}
+	expect := []string{"email:email", "count:min", "state:oneof", "code:len", "items[0].name:required", "note:max"}
+	if !reflect.DeepEqual(fields, expect) {
+		t.Fatalf("expect %v, but got %v", expect, fields)
+	}
+
+	if err := Validate(&order{Email: "a@b.com", Count: 3, State: "paid", Code: "abcd", Items: []item{{Name: "x"}}}); err != nil {
+		t.Fatalf("valid struct should pass, got %v", err)
+	}
+
+	// 未知的规则返回错误，而不是跳过校验
+	type typo struct {
+		Name string `json:"name" binding:"requird"`
+	}
+	err = Validate(&typo{Name: "gee"})
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Tag != "requird" || errs[0].Message != "invalid rule requird" {
+		t.Fatalf("unknown rule should be reported, got %v", err)
+	}
+}
+
+func TestBind(t *testing.T) {
+	engine := New()
+	engine.Get("/search", E(func(ctx *Context) error {
+		var s search
+		if err := ctx.Bind(&s); err != nil {
+			return err
+		}
+		ctx.JSON(http.StatusOK, s)
+		return nil
+	}))
+	engine.Post("/orders", E(func(ctx *Context) error {
+		var o order
+		if err := ctx.Bind(&o); err != nil {
+			return err
+		}
+		ctx.String(http.StatusOK, o.Email)
+		return nil
+	}))
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/search?q=go&page=2&tag=a&tag=b&debug=true", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"Tags":["a","b"]`) || !strings.Contains(res.Body.String(), `"Page":2`) {
+		t.Fatalf("query should be bound, got %d %s", res.Code, res.Body.String())
+	}
+
+	cases := []struct {
+		req    *http.Request
+		status int
+	}{
+		{httptest.NewRequest(http.MethodGet, "/search?page=x&q=go", nil), http.StatusBadRequest},
+		{httptest.NewRequest(http.MethodGet, "/search", nil), http.StatusBadRequest},
+		{httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{`)), http.StatusBadRequest},
+		{httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`a=1`)), http.StatusUnsupportedMediaType},
+	}
+	cases[2].req.Header.Set("Content-Type", "application/json")
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, c.req)
+		if res.Code != c.status {
+			t.Fatalf("%s %s expect %d, but got %d", c.req.Method, c.req.URL, c.status, res.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"email":"a@b.com","count":1,"state":"new","code":"abcd","items":[{"name":"x"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || res.Body.String() != "a@b.com" {
+		t.Fatalf("json should be bound, got %d %s", res.Code, res.Body.String())
+	}
+}
@@ -0,0 +1,137 @@
+package gee
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+/* ---------------------------------- 客户端IP ---------------------------------- */
+/**
+ * 经过负载均衡/反向代理后，RemoteAddr 是代理的地址，真实的客户端IP保存在 X-Forwarded-For 中：
+ *  X-Forwarded-For: client, proxy1, proxy2
+ * 该请求头可以被客户端伪造，因此只有直接连接的代理是可信的，才会从右向左跳过可信代理，
+ * 第一个不可信的地址即为客户端IP。默认不信任任何代理
+ */
+
+// 设置可信代理，支持 CIDR(10.0.0.0/8) 与单个IP(192.168.1.1)
+func (engine *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs, err := parseCIDRs(proxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedProxies = cidrs
+	return nil
+}
+
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	return containsIP(engine.trustedProxies, ip)
+}
+
+// 直接连接的对端IP，即 RemoteAddr 中的IP
+func (ctx *Context) RemoteIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(ctx.Req.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(ctx.Req.RemoteAddr)
+	}
+	return host
+}
+
+// 客户端的真实IP
+func (ctx *Context) ClientIP() string {
+	remoteIP := ctx.RemoteIP()
+	ip := net.ParseIP(remoteIP)
+	if ip == nil || ctx.engine == nil || !ctx.engine.isTrustedProxy(ip) {
+		return remoteIP
+	}
+
+	// 从右向左遍历，跳过可信代理
+	if values := ctx.Req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			hopIP := net.ParseIP(hop)
+			if hopIP == nil {
+				break
+			}
+			if i == 0 || !ctx.engine.isTrustedProxy(hopIP) {
+				return hop
+			}
+		}
+	}
+
+	if realIP := strings.TrimSpace(ctx.Req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return remoteIP
+}
+
+/* ---------------------------------- IP 过滤 ---------------------------------- */
+
+type IPFilterConfig struct {
+	// 允许的IP/CIDR，为空时允许所有
+	Allow []string
+	// 拒绝的IP/CIDR，优先于 Allow
+	Deny []string
+}
+
+// 根据客户端IP过滤请求，不满足时返回 403
+func IPFilter(config IPFilterConfig) HandlerFunc {
+	allow, err := parseCIDRs(config.Allow)
+	if err != nil {
+		panic(err)
+	}
+	deny, err := parseCIDRs(config.Deny)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(ctx *Context) {
+		ip := net.ParseIP(ctx.ClientIP())
+		if ip == nil || containsIP(deny, ip) || (len(allow) > 0 && !containsIP(allow, ip)) {
+			ctx.FatalT(http.StatusForbidden, "gee.forbidden")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP: %q", value)
+			}
+			// 单个IP转换成 /32 或 /128
+			bits := 128
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, cidr, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+
+	return cidrs, nil
+}
+
+func containsIP(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	engine := New()
+	if err := engine.SetTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	engine.Get("/ip", func(ctx *Context) {
+		ctx.String(http.StatusOK, "%s %s", ctx.ClientIP(), ctx.RemoteIP())
+	})
+
+	cases := []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expect     string
+	}{
+		// 不可信的对端，忽略请求头
+		{"8.8.8.8:1234", "1.1.1.1", "", "8.8.8.8 8.8.8.8"},
+		// 跳过可信代理，取第一个不可信的地址
+		{"10.0.0.1:1234", "1.1.1.1, 2.2.2.2, 192.168.1.1, 10.0.0.2", "", "2.2.2.2 10.0.0.1"},
+		// 全部可信时取最左边的地址
+		{"10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3 10.0.0.1"},
+		// 遇到不合法的地址时停止
+		{"10.0.0.1:1234", "1.1.1.1, unknown, 10.0.0.2", "3.3.3.3", "3.3.3.3 10.0.0.1"},
+		{"192.168.1.1:1234", "", "3.3.3.3", "3.3.3.3 192.168.1.1"},
+		{"[::1]:1234", "", "", "::1 ::1"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.realIP != "" {
+			req.Header.Set("X-Real-IP", c.realIP)
+		}
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, req)
+		if res.Body.String() != c.expect {
+			t.Fatalf("remote %s, forwarded %q: expect %q, but got %q", c.remoteAddr, c.forwarded, c.expect, res.Body.String())
+		}
+	}
+
+	if err := engine.SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("invalid proxy should be rejected")
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	engine := New()
+	engine.Use(IPFilter(IPFilterConfig{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.0.0.66"}}))
+	engine.Get("/", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	expects := map[string]int{
+		"10.1.2.3:80":  http.StatusOK,
+		"10.0.0.66:80": http.StatusForbidden,
+		"8.8.8.8:80":   http.StatusForbidden,
+	}
+	for remoteAddr, expect := range expects {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, req)
+		if res.Code != expect {
+			t.Fatalf("%s expect %d, but got %d", remoteAddr, expect, res.Code)
+		}
+	}
+}
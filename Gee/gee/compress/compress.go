@@ -0,0 +1,300 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"gee-demo/gee"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/* ---------------------------------- 响应压缩 ---------------------------------- */
+/**
+ * 通过 Accept-Encoding 协商压缩算法(gzip/deflate)，替换 ctx.Res 为带缓冲的压缩 Writer
+ *  响应体小于 MinLength 时不压缩，原样输出
+ *  已设置 Content-Encoding、排除的类型/路径/扩展名，均不压缩
+ *  调用 Flush 时立即开始输出，支持流式响应
+ */
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+type Config struct {
+	// 压缩级别，为 nil 时使用 gzip.DefaultCompression，可以设置为 gzip.NoCompression
+	Level *int
+	// 最小压缩长度，默认 1024 字节
+	MinLength int
+	// 不压缩的 Content-Type 前缀，如 image/
+	ExcludedContentTypes []string
+	// 不压缩的路径前缀
+	ExcludedPaths []string
+	// 不压缩的扩展名，如 .png
+	ExcludedExtensions []string
+}
+
+var (
+	defaultExcludedContentTypes = []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/x-gzip"}
+	defaultExcludedExtensions   = []string{".png", ".gif", ".jpeg", ".jpg", ".webp", ".mp3", ".mp4", ".zip", ".gz", ".tgz", ".woff2"}
+)
+
+// 默认配置
+func Default() gee.HandlerFunc {
+	return New(Config{})
+}
+
+// 根据配置实例化一个压缩中间件
+func New(config Config) gee.HandlerFunc {
+	level := gzip.DefaultCompression
+	if config.Level != nil {
+		level = *config.Level
+	}
+	// 在实例化时校验压缩级别，避免请求时才在 sync.Pool 中 panic
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+	if config.MinLength <= 0 {
+		config.MinLength = 1024
+	}
+	if config.ExcludedContentTypes == nil {
+		config.ExcludedContentTypes = defaultExcludedContentTypes
+	}
+	if config.ExcludedExtensions == nil {
+		config.ExcludedExtensions = defaultExcludedExtensions
+	}
+
+	pools := map[string]*sync.Pool{
+		encodingGzip: {New: func() interface{} {
+			w, err := gzip.NewWriterLevel(io.Discard, level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+		encodingDeflate: {New: func() interface{} {
+			w, err := flate.NewWriter(io.Discard, level)
+			if err != nil {
+				panic(err)
+			}
+			return w
+		}},
+	}
+
+	return func(ctx *gee.Context) {
+		if config.excludedPath(ctx.Path) {
+			ctx.Next()
+			return
+		}
+
+		// 响应内容随 Accept-Encoding 变化，告知缓存
+		ctx.Res.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiate(ctx.Req.Header.Get("Accept-Encoding"))
+		if encoding == "" || ctx.Method == http.MethodHead || ctx.Req.Header.Get("Upgrade") != "" {
+			ctx.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: ctx.Res,
+			config:         &config,
+			encoding:       encoding,
+			pool:           pools[encoding],
+		}
+		ctx.Res = writer
+		defer func() {
+			writer.close()
+			ctx.Res = writer.ResponseWriter
+		}()
+
+		ctx.Next()
+	}
+}
+
+func (config *Config) excludedPath(p string) bool {
+	for _, prefix := range config.ExcludedPaths {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(p))
+	if ext == "" {
+		return false
+	}
+	for _, e := range config.ExcludedExtensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (config *Config) excludedContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range config.ExcludedContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析 Accept-Encoding，优先 gzip，q=0 表示不接受
+func negotiate(acceptEncoding string) string {
+	var gzipQ, deflateQ float64 = -1, -1
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case encodingGzip:
+			gzipQ = q
+		case encodingDeflate:
+			deflateQ = q
+		case "*":
+			if gzipQ < 0 {
+				gzipQ = q
+			}
+		}
+	}
+
+	if gzipQ > 0 && gzipQ >= deflateQ {
+		return encodingGzip
+	}
+	if deflateQ > 0 {
+		return encodingDeflate
+	}
+	return ""
+}
+
+/* ------------------------------ 压缩 ResponseWriter ----------------------------- */
+
+// gzip.Writer 与 flate.Writer 共同的方法
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	config   *Config
+	encoding string
+	pool     *sync.Pool
+
+	// 暂存的状态码，决定是否压缩后才真正写入
+	status int
+	// 是否已经决定压缩与否
+	decided bool
+	// 决定之前缓冲的响应体
+	buf []byte
+	// 压缩器，为 nil 表示不压缩
+	encoder encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		// 已经被编码过的响应，直接透传
+		if w.Header().Get("Content-Encoding") != "" {
+			w.decide(false)
+		} else {
+			w.buf = append(w.buf, b...)
+			if len(w.buf) >= w.config.MinLength {
+				w.decide(true)
+			}
+			return len(b), nil
+		}
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// 实现 http.Flusher，流式输出时立即开始压缩并刷新
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// 决定是否压缩，并写入状态码与缓冲的数据
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	header := w.Header()
+
+	if compress && w.compressible() {
+		// 未设置 Content-Type 时，需要根据原始数据推断，否则 net/http 会根据压缩后的数据推断
+		if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+			header.Set("Content-Type", http.DetectContentType(w.buf))
+		}
+		if !w.config.excludedContentType(header.Get("Content-Type")) {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			w.encoder = w.pool.Get().(encoder)
+			w.encoder.Reset(w.ResponseWriter)
+		}
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	if len(w.buf) > 0 {
+		if w.encoder != nil {
+			w.encoder.Write(w.buf)
+		} else {
+			w.ResponseWriter.Write(w.buf)
+		}
+	}
+	w.buf = nil
+}
+
+func (w *compressWriter) compressible() bool {
+	switch w.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	return w.Header().Get("Content-Encoding") == ""
+}
+
+// 请求结束，输出剩余数据并归还压缩器
+func (w *compressWriter) close() {
+	if !w.decided {
+		// 响应体太小，不压缩
+		w.decide(false)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.pool.Put(w.encoder)
+		w.encoder = nil
+	}
+}
@@ -0,0 +1,136 @@
+package compress
+
+import (
+	"compress/gzip"
+	"gee-demo/gee"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var large = strings.Repeat("gee ", 1024)
+
+func newEngine() *gee.Engine {
+	engine := gee.New()
+	engine.Use(New(Config{MinLength: 256, ExcludedPaths: []string{"/raw"}}))
+	engine.Get("/large", func(ctx *gee.Context) {
+		ctx.JSON(http.StatusOK, gee.H{"data": large})
+	})
+	engine.Get("/small", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "small")
+	})
+	engine.Get("/raw", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, large)
+	})
+	engine.Get("/encoded", func(ctx *gee.Context) {
+		ctx.SetHeader("Content-Encoding", "br")
+		ctx.String(http.StatusOK, large)
+	})
+	engine.Get("/stream", func(ctx *gee.Context) {
+		ctx.SetHeader("Content-Type", "text/event-stream")
+		ctx.Status(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			ctx.Res.Write([]byte("data: gee\n\n"))
+			ctx.Res.(http.Flusher).Flush()
+		}
+	})
+	return engine
+}
+
+func request(engine *gee.Engine, path string, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func gunzip(t *testing.T, body io.Reader) string {
+	reader, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	return string(data)
+}
+
+func TestCompress(t *testing.T) {
+	engine := newEngine()
+
+	res := request(engine, "/large", "deflate;q=0.5, gzip")
+	if res.Header().Get("Content-Encoding") != "gzip" || res.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("large response should be gzipped, headers %v", res.Header())
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("content type should be kept, got %q", res.Header().Get("Content-Type"))
+	}
+	if body := gunzip(t, res.Body); !strings.Contains(body, large) {
+		t.Fatalf("gunzip body mismatch")
+	}
+
+	// 复用池中的压缩器
+	res = request(engine, "/large", "gzip")
+	if body := gunzip(t, res.Body); !strings.Contains(body, large) {
+		t.Fatalf("gunzip body mismatch with pooled writer")
+	}
+}
+
+func TestSkip(t *testing.T) {
+	engine := newEngine()
+
+	cases := map[string]string{
+		"/small":   "gzip",
+		"/raw":     "gzip",
+		"/large":   "gzip;q=0, identity",
+		"/encoded": "gzip",
+	}
+	for path, acceptEncoding := range cases {
+		res := request(engine, path, acceptEncoding)
+		if got := res.Header().Get("Content-Encoding"); got == "gzip" {
+			t.Fatalf("%s with %q should not be gzipped", path, acceptEncoding)
+		}
+		if res.Code != http.StatusOK || res.Body.Len() == 0 {
+			t.Fatalf("%s should be served, status %d", path, res.Code)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	engine := newEngine()
+
+	res := request(engine, "/stream", "gzip")
+	if !res.Flushed || res.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("stream should be flushed and gzipped")
+	}
+	if body := gunzip(t, res.Body); body != strings.Repeat("data: gee\n\n", 3) {
+		t.Fatalf("stream body mismatch: %q", body)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	level := gzip.NoCompression
+	engine := gee.New()
+	engine.Use(New(Config{Level: &level, MinLength: 256}))
+	engine.Get("/large", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, large)
+	})
+
+	// 不压缩时只做 gzip 封装，响应体不会变小
+	res := request(engine, "/large", "gzip")
+	if res.Header().Get("Content-Encoding") != "gzip" || res.Body.Len() <= len(large) {
+		t.Fatalf("no compression level should be kept, got %d bytes", res.Body.Len())
+	}
+	if body := gunzip(t, res.Body); body != large {
+		t.Fatalf("body mismatch")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("invalid level should panic on construction")
+		}
+	}()
+	invalid := 42
+	New(Config{Level: &invalid})
+}
@@ -3,7 +3,12 @@ package gee
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
 )
 
 // 给map[string]interface{}起了一个别名gee.H，构建JSON数据时，显得更简洁
@@ -18,24 +23,59 @@ type Context struct {
 	Path   string
 	Method string
 	Params map[string]string
+	// 匹配到的路由规则，如 /p/:lang/doc，未匹配时为空
+	Pattern string
 	// res
 	StatusCode int
+	// 处理过程中产生的错误
+	Errors []error
 	// middlewares
 	middlewares []HandlerFunc
 	// 当前middlewares的执行位置
 	index int
 	// engine
 	engine *Engine
+	// 请求范围内的键值存储，用于在中间件之间传递数据
+	keys      map[string]interface{}
+	keysMutex *sync.RWMutex
+	// 请求范围内的模板函数，覆盖engine中的同名函数
+	funcMap template.FuncMap
+	// 解析后的Query与表单参数，第一次访问时解析
+	queryCache url.Values
+	formCache  url.Values
 }
 
 // 工厂函数，实例化一个Context
 func newContext(res http.ResponseWriter, req *http.Request) *Context {
 	return &Context{
-		Req:    req,
-		Res:    res,
-		Path:   req.URL.Path,
-		Method: req.Method,
-		index:  -1,
+		Req:       req,
+		Res:       res,
+		Path:      req.URL.Path,
+		Method:    req.Method,
+		index:     -1,
+		keys:      make(map[string]interface{}),
+		keysMutex: &sync.RWMutex{},
+	}
+}
+
+// 复制一个Context，与原Context共享请求，使用新的响应Writer，用于在其他goroutine中继续处理请求
+func (ctx *Context) fork(res http.ResponseWriter) *Context {
+	return &Context{
+		Req:         ctx.Req,
+		Res:         res,
+		Path:        ctx.Path,
+		Method:      ctx.Method,
+		Params:      ctx.Params,
+		Pattern:     ctx.Pattern,
+		StatusCode:  ctx.StatusCode,
+		middlewares: ctx.middlewares,
+		index:       ctx.index,
+		engine:      ctx.engine,
+		keys:        ctx.keys,
+		keysMutex:   ctx.keysMutex,
+		funcMap:     ctx.funcMap,
+		queryCache:  ctx.queryCache,
+		formCache:   ctx.formCache,
 	}
 }
 
@@ -47,6 +87,10 @@ func (ctx *Context) Next() {
 	// 	ctx.middlewares[ctx.index](ctx)
 	// }
 	ctx.index++
+	// 已经执行到最后，或被 Fatal/Error 终止
+	if ctx.index >= len(ctx.middlewares) {
+		return
+	}
 	// 执行接下来的中间件
 	ctx.middlewares[ctx.index](ctx)
 }
@@ -56,14 +100,152 @@ func (ctx *Context) Param(key string) string {
 	return ctx.Params[key]
 }
 
+/* -------------------------------- 键值存储 -------------------------------- */
+// 存储键值，fork出的Context共享同一份存储
+func (ctx *Context) Set(key string, value interface{}) {
+	ctx.keysMutex.Lock()
+	defer ctx.keysMutex.Unlock()
+
+	ctx.keys[key] = value
+}
+
+// 获取键值
+func (ctx *Context) Get(key string) (value interface{}, ok bool) {
+	ctx.keysMutex.RLock()
+	defer ctx.keysMutex.RUnlock()
+
+	value, ok = ctx.keys[key]
+	return
+}
+
+// 获取键值，不存在时panic
+func (ctx *Context) MustGet(key string) interface{} {
+	if value, ok := ctx.Get(key); ok {
+		return value
+	}
+	panic("Key \"" + key + "\" does not exist")
+}
+
+// 获取字符串类型的键值
+func (ctx *Context) GetString(key string) (s string) {
+	if value, ok := ctx.Get(key); ok {
+		s, _ = value.(string)
+	}
+	return
+}
+
+/* -------------------------------- Query & Form -------------------------------- */
+// 解析并缓存Query参数
+func (ctx *Context) queryValues() url.Values {
+	if ctx.queryCache == nil {
+		ctx.queryCache = ctx.Req.URL.Query()
+	}
+	return ctx.queryCache
+}
+
+// 解析并缓存表单参数，与 Req.Form 一致，包含请求体与Query参数，请求体优先
+func (ctx *Context) formValues() url.Values {
+	if ctx.formCache == nil {
+		if err := ctx.Req.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+			log.Printf("[E - Form] %s: %v", ctx.Req.RequestURI, err)
+		}
+		ctx.formCache = ctx.Req.Form
+		if ctx.formCache == nil {
+			ctx.formCache = make(url.Values)
+		}
+	}
+	return ctx.formCache
+}
+
 // 获取表单属性
 func (ctx *Context) PostForm(key string) string {
-	return ctx.Req.FormValue(key)
+	value, _ := ctx.GetPostForm(key)
+	return value
+}
+
+// 获取表单属性，不存在时返回默认值
+func (ctx *Context) DefaultPostForm(key string, defaultValue string) string {
+	if value, ok := ctx.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// 获取表单属性，并返回是否存在
+func (ctx *Context) GetPostForm(key string) (string, bool) {
+	if values, ok := ctx.formValues()[key]; ok && len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
 }
 
 // 查询Query值
 func (ctx *Context) Query(key string) string {
-	return ctx.Req.URL.Query().Get(key)
+	value, _ := ctx.GetQuery(key)
+	return value
+}
+
+// 查询Query值，不存在时返回默认值
+func (ctx *Context) DefaultQuery(key string, defaultValue string) string {
+	if value, ok := ctx.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// 查询Query值，并返回是否存在，用于区分 ?a= 与没有 a
+func (ctx *Context) GetQuery(key string) (string, bool) {
+	if values, ok := ctx.queryValues()[key]; ok && len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// 查询同名的多个Query值，如 ?id=1&id=2
+func (ctx *Context) QueryArray(key string) []string {
+	return ctx.queryValues()[key]
+}
+
+// 查询 key[name]=value 形式的Query值，如 ?filter[name]=gee&filter[age]=1
+func (ctx *Context) QueryMap(key string) map[string]string {
+	dict := make(map[string]string)
+	for k, values := range ctx.queryValues() {
+		// 只匹配 key[name] 且 name 不为空
+		if len(k) > len(key)+2 && strings.HasPrefix(k, key+"[") && k[len(k)-1] == ']' && len(values) > 0 {
+			dict[k[len(key)+1:len(k)-1]] = values[0]
+		}
+	}
+	return dict
+}
+
+// 获取请求头
+func (ctx *Context) GetHeader(key string) string {
+	return ctx.Req.Header.Get(key)
+}
+
+/* -------------------------------- Cookie -------------------------------- */
+// 获取请求中的cookie值，cookie不存在时返回 http.ErrNoCookie
+func (ctx *Context) Cookie(name string) (string, error) {
+	cookie, err := ctx.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// 设置cookie，Path默认为"/"，SameSite默认为Lax
+func (ctx *Context) SetCookie(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	// SameSite=None 必须同时设置 Secure，否则浏览器会拒绝
+	if cookie.SameSite == http.SameSiteNoneMode {
+		cookie.Secure = true
+	}
+	http.SetCookie(ctx.Res, cookie)
 }
 
 // 设置状态码
@@ -102,18 +284,42 @@ func (ctx *Context) Fatal(code int, message string) {
 	ctx.JSON(code, H{"message": message})
 }
 
+// 处理错误，message 为按当前请求的语言翻译 key 得到的文本
+func (ctx *Context) FatalT(code int, key string, args ...interface{}) {
+	ctx.Fatal(code, ctx.T(key, args...))
+}
+
 // 直接返回data
 func (ctx *Context) Data(code int, data []byte) {
 	ctx.Status(code)
 	ctx.Res.Write(data)
 }
 
+// 设置请求范围内的模板函数，如CSRF令牌、CSP nonce
+// 模板解析时函数必须已经存在，因此需要先通过 engine.AddFuncMap 注册同名的函数
+func (ctx *Context) SetTemplateFunc(name string, fn interface{}) {
+	if ctx.funcMap == nil {
+		ctx.funcMap = make(template.FuncMap)
+	}
+	ctx.funcMap[name] = fn
+}
+
 // 返回HTML
 func (ctx *Context) HTML(code int, templateName string, data interface{}) {
 	ctx.SetHeader("Content-Type", "text/html")
 	ctx.Status(code)
 	// ctx.Res.Write([]byte(html))
-	if err := ctx.engine.htmlTemplates.ExecuteTemplate(ctx.Res, templateName, data); err != nil {
+	templates := ctx.engine.htmlTemplates
+	// 存在请求范围内的模板函数时，复制一份模板再替换函数，不影响其他请求
+	if len(ctx.funcMap) > 0 {
+		clone, err := templates.Clone()
+		if err != nil {
+			ctx.Fatal(500, err.Error())
+			return
+		}
+		templates = clone.Funcs(ctx.funcMap)
+	}
+	if err := templates.ExecuteTemplate(ctx.Res, templateName, data); err != nil {
 		ctx.Fatal(500, err.Error())
 	}
 }
@@ -0,0 +1,58 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?name=gee&empty=&id=1&id=2&filter[name]=a&filter[age]=1&filter[]=x&filters[name]=b", nil)
+	ctx := newContext(httptest.NewRecorder(), req)
+
+	if ctx.Query("name") != "gee" || ctx.DefaultQuery("missing", "default") != "default" || ctx.DefaultQuery("empty", "default") != "" {
+		t.Fatalf("unexpected query values")
+	}
+	if value, ok := ctx.GetQuery("empty"); !ok || value != "" {
+		t.Fatalf("empty query should exist")
+	}
+	if _, ok := ctx.GetQuery("missing"); ok {
+		t.Fatalf("missing query should not exist")
+	}
+	if !reflect.DeepEqual(ctx.QueryArray("id"), []string{"1", "2"}) {
+		t.Fatalf("unexpected query array %v", ctx.QueryArray("id"))
+	}
+	if expect := map[string]string{"name": "a", "age": "1"}; !reflect.DeepEqual(ctx.QueryMap("filter"), expect) {
+		t.Fatalf("expect %v, but got %v", expect, ctx.QueryMap("filter"))
+	}
+
+	// 解析结果被缓存
+	req.URL.RawQuery = "name=changed"
+	if ctx.Query("name") != "gee" {
+		t.Fatalf("query should be cached")
+	}
+}
+
+func TestPostForm(t *testing.T) {
+	body := url.Values{"name": {"gee"}, "empty": {""}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/?name=query&page=2", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Token", "secret")
+	ctx := newContext(httptest.NewRecorder(), req)
+
+	if ctx.PostForm("name") != "gee" || ctx.PostForm("page") != "2" {
+		t.Fatalf("body values should take precedence over query")
+	}
+	if ctx.DefaultPostForm("missing", "default") != "default" || ctx.DefaultPostForm("empty", "default") != "" {
+		t.Fatalf("unexpected default form values")
+	}
+	if _, ok := ctx.GetPostForm("missing"); ok {
+		t.Fatalf("missing form value should not exist")
+	}
+	if ctx.GetHeader("X-Token") != "secret" {
+		t.Fatalf("unexpected header")
+	}
+}
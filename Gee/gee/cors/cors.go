@@ -0,0 +1,174 @@
+package cors
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ---------------------------------- 跨域资源共享 ---------------------------------- */
+/**
+ * 浏览器跨域请求分为两类：
+ *  简单请求：直接发送，响应中需要携带 Access-Control-Allow-Origin 等头
+ *  预检请求：先发送 OPTIONS + Access-Control-Request-Method，服务端同意后才会发送真正的请求
+ * 预检请求由中间件直接应答，不会进入路由，因此不需要注册 OPTIONS 路由
+ */
+
+type Config struct {
+	// 允许的源，支持精确匹配、"*" 以及通配符，如 https://*.example.com
+	AllowOrigins []string
+	// 自定义源校验函数，优先级低于 AllowOrigins
+	AllowOriginFunc func(origin string) bool
+	// 允许的方法，为空时使用默认值
+	AllowMethods []string
+	// 允许的请求头，为空时回显预检请求中的 Access-Control-Request-Headers
+	AllowHeaders []string
+	// 允许浏览器读取的响应头
+	ExposeHeaders []string
+	// 是否允许携带 cookie 等凭证
+	AllowCredentials bool
+	// 预检结果的缓存时间
+	MaxAge time.Duration
+}
+
+var defaultMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// 默认配置：允许所有源的常用请求
+func Default() gee.HandlerFunc {
+	return New(Config{
+		AllowOrigins: []string{"*"},
+		MaxAge:       12 * time.Hour,
+	})
+}
+
+// 根据配置实例化一个CORS中间件
+func New(config Config) gee.HandlerFunc {
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = defaultMethods
+	}
+
+	matcher := newOriginMatcher(config.AllowOrigins, config.AllowOriginFunc)
+	allowMethods := strings.Join(normalize(config.AllowMethods, strings.ToUpper), ", ")
+	allowHeaders := strings.Join(normalize(config.AllowHeaders, http.CanonicalHeaderKey), ", ")
+	exposeHeaders := strings.Join(normalize(config.ExposeHeaders, http.CanonicalHeaderKey), ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+
+	return func(ctx *gee.Context) {
+		origin := ctx.Req.Header.Get("Origin")
+		// 非跨域请求，直接放行
+		if origin == "" {
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Res.Header()
+		header.Add("Vary", "Origin")
+		preflight := ctx.Method == http.MethodOptions && ctx.Req.Header.Get("Access-Control-Request-Method") != ""
+
+		if !matcher.allow(origin) {
+			// 预检失败直接拒绝，普通请求则不带CORS头，交由浏览器拦截
+			if preflight {
+				ctx.Status(http.StatusForbidden)
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		// 携带凭证时不能使用 *，必须回显具体的源
+		if matcher.any && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			ctx.Next()
+			return
+		}
+
+		// 预检请求
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if reqHeaders := ctx.Req.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", reqHeaders)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		// 不再执行后续中间件和路由
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+/* ---------------------------------- 源匹配 ---------------------------------- */
+
+type originMatcher struct {
+	// 是否允许所有源
+	any bool
+	// 精确匹配的源
+	exact map[string]bool
+	// 通配符匹配的源，[前缀, 后缀]
+	wildcards [][2]string
+	fn        func(origin string) bool
+}
+
+func newOriginMatcher(origins []string, fn func(origin string) bool) *originMatcher {
+	matcher := &originMatcher{exact: make(map[string]bool), fn: fn}
+
+	for _, origin := range origins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			matcher.any = true
+		} else if i := strings.IndexByte(origin, '*'); i >= 0 {
+			matcher.wildcards = append(matcher.wildcards, [2]string{origin[:i], origin[i+1:]})
+		} else {
+			matcher.exact[origin] = true
+		}
+	}
+
+	return matcher
+}
+
+func (matcher *originMatcher) allow(origin string) bool {
+	if matcher.any {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if matcher.exact[lower] {
+		return true
+	}
+	for _, w := range matcher.wildcards {
+		// 通配符至少匹配一个字符
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+
+	return matcher.fn != nil && matcher.fn(origin)
+}
+
+func normalize(values []string, fn func(string) string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, fn(v))
+		}
+	}
+	return result
+}
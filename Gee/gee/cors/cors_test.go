@@ -0,0 +1,92 @@
+package cors
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newEngine(config Config) *gee.Engine {
+	engine := gee.New()
+	engine.Use(New(config))
+	engine.Get("/ping", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+	return engine
+}
+
+func TestPreflight(t *testing.T) {
+	engine := newEngine(Config{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowHeaders:     []string{"content-type"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("preflight status expect 204, but got %d", res.Code)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Fatalf("allow origin expect echo, but got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Fatalf("allow headers got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Fatalf("max age got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("allow credentials got %q", got)
+	}
+}
+
+func TestDisallowedOrigin(t *testing.T) {
+	engine := newEngine(Config{AllowOrigins: []string{"https://a.com"}})
+
+	req := httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	req.Header.Set("Origin", "https://b.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("preflight from disallowed origin expect 403, but got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://b.com")
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || res.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("simple request from disallowed origin should not carry cors headers")
+	}
+}
+
+func TestSimpleRequest(t *testing.T) {
+	engine := newEngine(Config{
+		AllowOriginFunc: func(origin string) bool { return origin == "https://c.com" },
+		ExposeHeaders:   []string{"x-total-count"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://c.com")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	if res.Body.String() != "pong" {
+		t.Fatalf("handler should be called, body %q", res.Body.String())
+	}
+	if got := res.Header().Get("Access-Control-Allow-Origin"); got != "https://c.com" {
+		t.Fatalf("allow origin got %q", got)
+	}
+	if got := res.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Fatalf("expose headers got %q", got)
+	}
+}
@@ -0,0 +1,176 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"gee-demo/gee"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+/* ---------------------------------- CSRF 防御 ---------------------------------- */
+/**
+ * 每个客户端持有一个随机的 secret，保存在 Store 中：
+ *  CookieStore：双重提交 cookie，secret 保存在 cookie 中
+ *  SessionStore：同步器令牌，secret 保存在服务端会话中
+ * 对外暴露的 token 是用一次性随机数掩码后的 secret，每次请求都不同，防止 BREACH 攻击
+ * 对于不安全的方法(POST/PUT/DELETE...)，从请求头或表单中读取 token，与 secret 比较
+ */
+
+const (
+	// token 在 ctx 键值存储中的key
+	tokenKey = "gee.csrf.token"
+	// secret 的长度
+	secretLen = 32
+)
+
+type Config struct {
+	// secret 的存储，默认 CookieStore("_csrf")
+	Store Store
+	// 读取 token 的请求头，默认 X-CSRF-Token
+	HeaderName string
+	// 读取 token 的表单字段，默认 _csrf
+	FieldName string
+	// 不校验的路径，以 * 结尾时按前缀匹配
+	ExemptPaths []string
+	// 校验失败时的处理，默认返回 403
+	ErrorHandler gee.HandlerFunc
+}
+
+// 根据配置实例化一个CSRF中间件
+func New(config Config) gee.HandlerFunc {
+	if config.Store == nil {
+		config.Store = CookieStore(CookieOptions{Name: "_csrf"})
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FieldName == "" {
+		config.FieldName = "_csrf"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(ctx *gee.Context) {
+			ctx.FatalT(http.StatusForbidden, "csrf.invalid_token")
+		}
+	}
+
+	return func(ctx *gee.Context) {
+		if config.exempt(ctx.Path) {
+			ctx.Next()
+			return
+		}
+
+		secret := config.Store.Get(ctx)
+		if len(secret) != secretLen {
+			secret = newSecret()
+			config.Store.Save(ctx, secret)
+		}
+
+		token := mask(secret)
+		ctx.Set(tokenKey, token)
+		ctx.SetTemplateFunc("csrfToken", func() string { return token })
+		ctx.SetTemplateFunc("csrfField", func() string { return field(config.FieldName, token) })
+		// token 每次都不同，响应不能被缓存
+		ctx.Res.Header().Add("Vary", "Cookie")
+
+		if !safeMethod(ctx.Method) {
+			sent := ctx.Req.Header.Get(config.HeaderName)
+			if sent == "" {
+				sent = ctx.PostForm(config.FieldName)
+			}
+			if !valid(sent, secret) {
+				config.ErrorHandler(ctx)
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// 获取当前请求的 token，用于放入响应头或 JSON 中
+func Token(ctx *gee.Context) string {
+	return ctx.GetString(tokenKey)
+}
+
+// 模板函数，需要在 LoadHTMLGlob 之前注册，实际的值由中间件按请求设置
+//
+//	router.AddFuncMap(csrf.FuncMap())
+//	<form method="post">{{ csrfField }}</form>
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string { return "" },
+		"csrfField": func() string { return "" },
+	}
+}
+
+func (config *Config) exempt(path string) bool {
+	for _, p := range config.ExemptPaths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RFC 7231 中定义的安全方法
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func field(name string, token string) string {
+	return `<input type="hidden" name="` + template.HTMLEscapeString(name) + `" value="` + token + `">`
+}
+
+func newSecret() []byte {
+	secret := make([]byte, secretLen)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// token = base64(pad | pad ^ secret)
+func mask(secret []byte) string {
+	b := make([]byte, 2*secretLen)
+	pad, masked := b[:secretLen], b[secretLen:]
+	if _, err := rand.Read(pad); err != nil {
+		panic(err)
+	}
+	for i := range secret {
+		masked[i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// 同时接受掩码后的 token，以及前端直接从 cookie 中读取的 secret
+func valid(token string, secret []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false
+	}
+
+	switch len(b) {
+	case 2 * secretLen:
+		pad, masked := b[:secretLen], b[secretLen:]
+		for i := range masked {
+			masked[i] ^= pad[i]
+		}
+		b = masked
+	case secretLen:
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(b, secret) == 1
+}
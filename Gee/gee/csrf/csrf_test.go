@@ -0,0 +1,118 @@
+package csrf
+
+import (
+	"gee-demo/gee"
+	"gee-demo/gee/sessions"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var tokenPattern = regexp.MustCompile(`value="([^"]+)"`)
+
+func newEngine(t *testing.T, middlewares ...gee.HandlerFunc) *gee.Engine {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "form.tmpl"), []byte(`<form method="post">{{ csrfField }}</form>`), 0644)
+
+	engine := gee.New()
+	engine.AddFuncMap(FuncMap())
+	engine.LoadHTMLGlob(filepath.Join(dir, "*"))
+	engine.Use(middlewares...)
+	engine.Get("/form", func(ctx *gee.Context) {
+		ctx.HTML(http.StatusOK, "form.tmpl", nil)
+	})
+	engine.Post("/form", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	engine.Post("/webhook/github", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	return engine
+}
+
+// 获取表单页面，返回其中的token与响应的cookie
+func getForm(t *testing.T, engine *gee.Engine, cookies []*http.Cookie) (string, []*http.Cookie) {
+	req := httptest.NewRequest(http.MethodGet, "/form", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	match := tokenPattern.FindStringSubmatch(res.Body.String())
+	if match == nil {
+		t.Fatalf("csrf field not rendered: %q", res.Body.String())
+	}
+	if len(res.Result().Cookies()) > 0 {
+		cookies = res.Result().Cookies()
+	}
+	return match[1], cookies
+}
+
+func postForm(engine *gee.Engine, path string, token string, cookies []*http.Cookie) int {
+	form := url.Values{"_csrf": {token}}
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res.Code
+}
+
+func testProtection(t *testing.T, engine *gee.Engine) {
+	token, cookies := getForm(t, engine, nil)
+	if code := postForm(engine, "/form", token, cookies); code != http.StatusOK {
+		t.Fatalf("valid token should pass, got %d", code)
+	}
+
+	// 每次渲染的 token 不同，但都有效
+	another, _ := getForm(t, engine, cookies)
+	if another == token {
+		t.Fatalf("token should be masked differently on each request")
+	}
+	if code := postForm(engine, "/form", another, cookies); code != http.StatusOK {
+		t.Fatalf("another valid token should pass, got %d", code)
+	}
+
+	if code := postForm(engine, "/form", "", cookies); code != http.StatusForbidden {
+		t.Fatalf("missing token should be rejected, got %d", code)
+	}
+	if code := postForm(engine, "/form", token, nil); code != http.StatusForbidden {
+		t.Fatalf("token without secret should be rejected, got %d", code)
+	}
+	if code := postForm(engine, "/webhook/github", "", nil); code != http.StatusOK {
+		t.Fatalf("exempt path should pass, got %d", code)
+	}
+}
+
+func TestDoubleSubmitCookie(t *testing.T) {
+	engine := newEngine(t, New(Config{ExemptPaths: []string{"/webhook/*"}}))
+	testProtection(t, engine)
+
+	// 前端直接读取 cookie 并放入请求头
+	_, cookies := getForm(t, engine, nil)
+	req := httptest.NewRequest(http.MethodPost, "/form", nil)
+	req.Header.Set("X-CSRF-Token", cookies[0].Value)
+	req.AddCookie(cookies[0])
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("header token should pass, got %d", res.Code)
+	}
+}
+
+func TestSynchronizerToken(t *testing.T) {
+	store, _ := sessions.NewMemoryStore([]byte("hash-key"))
+	engine := newEngine(t,
+		sessions.Sessions("gee_session", store),
+		New(Config{Store: SessionStore(), ExemptPaths: []string{"/webhook/*"}}),
+	)
+	testProtection(t, engine)
+}
@@ -0,0 +1,84 @@
+package csrf
+
+import (
+	"encoding/base64"
+	"gee-demo/gee"
+	"gee-demo/gee/sessions"
+	"net/http"
+)
+
+/* ---------------------------------- secret 存储 ---------------------------------- */
+
+type Store interface {
+	// 获取保存的 secret，不存在时返回 nil
+	Get(ctx *gee.Context) []byte
+	Save(ctx *gee.Context, secret []byte)
+}
+
+type CookieOptions struct {
+	Name   string
+	Path   string
+	Domain string
+	// 有效期(秒)，默认 12 小时
+	MaxAge int
+	Secure bool
+	// 前端需要读取 cookie 并放入请求头时，不能设置 HttpOnly
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// 双重提交 cookie：secret 保存在 cookie 中，攻击者无法读取其他站点的 cookie，也就无法构造 token
+func CookieStore(options CookieOptions) Store {
+	if options.Name == "" {
+		options.Name = "_csrf"
+	}
+	if options.MaxAge == 0 {
+		options.MaxAge = 12 * 3600
+	}
+	return &cookieStore{options: options}
+}
+
+type cookieStore struct {
+	options CookieOptions
+}
+
+func (store *cookieStore) Get(ctx *gee.Context) []byte {
+	value, err := ctx.Cookie(store.options.Name)
+	if err != nil {
+		return nil
+	}
+	secret, _ := base64.RawURLEncoding.DecodeString(value)
+	return secret
+}
+
+func (store *cookieStore) Save(ctx *gee.Context, secret []byte) {
+	ctx.SetCookie(&http.Cookie{
+		Name:     store.options.Name,
+		Value:    base64.RawURLEncoding.EncodeToString(secret),
+		Path:     store.options.Path,
+		Domain:   store.options.Domain,
+		MaxAge:   store.options.MaxAge,
+		Secure:   store.options.Secure,
+		HttpOnly: store.options.HttpOnly,
+		SameSite: store.options.SameSite,
+	})
+}
+
+// 同步器令牌：secret 保存在服务端会话中，需要先使用 sessions 中间件
+func SessionStore() Store {
+	return sessionStore{}
+}
+
+// secret 在会话中的key
+const sessionSecretKey = "_csrf_secret"
+
+type sessionStore struct{}
+
+func (sessionStore) Get(ctx *gee.Context) []byte {
+	secret, _ := sessions.Default(ctx).Get(sessionSecretKey).([]byte)
+	return secret
+}
+
+func (sessionStore) Save(ctx *gee.Context, secret []byte) {
+	sessions.Default(ctx).Set(sessionSecretKey, secret)
+}
@@ -0,0 +1,193 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+/* ---------------------------------- 错误处理 ---------------------------------- */
+/**
+ * handler 可以直接返回 error，由 Engine.ErrorHandler 统一渲染，默认输出 RFC 7807 application/problem+json：
+ *  *HTTPError        使用其状态码与描述，状态码不合法时为 500
+ *  ValidationErrors  400，附带每个字段的错误
+ *  ErrNotFound       404，包装的错误内容只记录日志
+ *  其他错误          500，不向客户端暴露错误内容
+ */
+
+// 返回error的处理函数，通过 E 转换后注册到路由
+type HandlerFuncE func(*Context) error
+
+// 将 HandlerFuncE 转换为 HandlerFunc，与普通的 HandlerFunc 一起注册
+//
+//	router.Get("/users/:id", gee.E(getUser))
+func E(handler HandlerFuncE) HandlerFunc {
+	return func(ctx *Context) {
+		if err := handler(ctx); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// 资源不存在
+var ErrNotFound = errors.New("not found")
+
+// 带有状态码的错误
+type HTTPError struct {
+	Code int
+	// 问题类型的URI，默认 about:blank
+	Type string
+	// 返回给客户端的描述
+	Detail string
+	// 内部错误，只记录日志，不返回给客户端
+	Err error
+	// Detail 为文本 key 时的占位符参数
+	args H
+}
+
+func NewHTTPError(code int, detail string) *HTTPError {
+	return &HTTPError{Code: code, Detail: detail}
+}
+
+func (e *HTTPError) Error() string {
+	detail := e.Detail
+	if e.args != nil {
+		detail = FormatMessage(lookupMessage(detail), e.args)
+	}
+	if e.Err != nil {
+		return http.StatusText(e.Code) + ": " + detail + ": " + e.Err.Error()
+	}
+	return http.StatusText(e.Code) + ": " + detail
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// 包装内部错误
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// 单个字段的校验错误
+type FieldError struct {
+	Field string `json:"field"`
+	// 校验规则，如 required、min
+	Tag     string `json:"tag,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+	// 错误信息的 key，用于按请求的语言重新生成 Message
+	key string
+}
+
+// 错误信息中的占位符参数
+func (e FieldError) args() H {
+	return H{"field": e.Field, "tag": e.Tag, "param": e.Param}
+}
+
+// 校验错误
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// RFC 7807 问题详情
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// Detail 为文本 key 时的占位符参数
+	args H
+}
+
+// 记录错误并交给 Engine.ErrorHandler 处理，不再执行后续的中间件
+func (ctx *Context) Error(err error) {
+	ctx.Errors = append(ctx.Errors, err)
+	ctx.notifyError(err)
+	ctx.renderError(err)
+}
+
+// 交给 Engine.ErrorHandler 处理，不记录错误也不触发 OnError 钩子
+func (ctx *Context) renderError(err error) {
+	// 直接跳到中间件的最后
+	ctx.index = len(ctx.middlewares)
+
+	handler := DefaultErrorHandler
+	if ctx.engine != nil && ctx.engine.ErrorHandler != nil {
+		handler = ctx.engine.ErrorHandler
+	}
+	handler(ctx, err)
+}
+
+// 以 application/problem+json 返回问题详情
+func (ctx *Context) Problem(problem Problem) {
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	// 按当前请求的语言翻译
+	problem.Title = ctx.T(problem.Title)
+	problem.Detail = ctx.T(problem.Detail, problem.args)
+	if len(problem.Errors) > 0 {
+		errs := make([]FieldError, len(problem.Errors))
+		for i, e := range problem.Errors {
+			if e.key != "" {
+				e.Message = ctx.T(e.key, e.args())
+			}
+			errs[i] = e
+		}
+		problem.Errors = errs
+	}
+	if problem.Instance == "" {
+		problem.Instance = ctx.Path
+	}
+
+	ctx.SetHeader("Content-Type", "application/problem+json")
+	ctx.Status(problem.Status)
+	json.NewEncoder(ctx.Res).Encode(problem)
+}
+
+// 默认的错误处理，将错误转换为问题详情
+func DefaultErrorHandler(ctx *Context, err error) {
+	problem := ProblemFromError(err)
+	// 包装了 ErrNotFound 的错误可能带有内部信息，只记录日志
+	if problem.Status >= http.StatusInternalServerError || err != ErrNotFound && errors.Is(err, ErrNotFound) {
+		log.Printf("[E - Error] [%d] %s: %v", problem.Status, ctx.Req.RequestURI, err)
+	}
+	ctx.Problem(problem)
+}
+
+// 将错误转换为问题详情
+func ProblemFromError(err error) Problem {
+	var httpErr *HTTPError
+	var validationErrs ValidationErrors
+
+	switch {
+	case errors.As(err, &httpErr):
+		// 状态码不合法时 WriteHeader 会 panic
+		if httpErr.Code < 100 || httpErr.Code > 599 {
+			return Problem{Status: http.StatusInternalServerError}
+		}
+		return Problem{Type: httpErr.Type, Status: httpErr.Code, Detail: httpErr.Detail, args: httpErr.args}
+	case errors.As(err, &validationErrs):
+		return Problem{Status: http.StatusBadRequest, Detail: "validation failed", Errors: validationErrs}
+	case errors.Is(err, ErrNotFound):
+		return Problem{Status: http.StatusNotFound, Detail: ErrNotFound.Error()}
+	default:
+		return Problem{Status: http.StatusInternalServerError}
+	}
+}
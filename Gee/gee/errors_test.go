@@ -0,0 +1,107 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	engine := New()
+	engine.HandleMethodNotAllowed = true
+	// 未匹配的请求不触发 OnError
+	engine.OnError(func(ctx *Context, err error) {
+		if ctx.Path == "/missing" || ctx.Method == "DELETE" || ctx.Method == "PUT" {
+			t.Errorf("unmatched request should not notify error hooks: %v", err)
+		}
+	})
+	engine.Get("/forbidden", E(func(ctx *Context) error {
+		return NewHTTPError(http.StatusForbidden, "no permission").Wrap(errors.New("role mismatch"))
+	}))
+	engine.Get("/users/:id", E(func(ctx *Context) error {
+		return fmt.Errorf("user %s: %w", ctx.Param("id"), ErrNotFound)
+	}))
+	engine.Post("/users", E(func(ctx *Context) error {
+		return ValidationErrors{{Field: "name", Message: "is required"}}
+	}))
+	engine.Get("/internal", E(func(ctx *Context) error {
+		return errors.New("db password leaked")
+	}))
+	engine.Get("/invalid", E(func(ctx *Context) error {
+		return &HTTPError{Detail: "missing code"}
+	}))
+	engine.Get("/ok", E(func(ctx *Context) error {
+		ctx.String(http.StatusOK, "ok")
+		return nil
+	}))
+
+	cases := []struct {
+		method string
+		path   string
+		expect Problem
+	}{
+		{"GET", "/forbidden", Problem{Type: "about:blank", Title: "Forbidden", Status: 403, Detail: "no permission", Instance: "/forbidden"}},
+		{"GET", "/users/1", Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "not found", Instance: "/users/1"}},
+		{"GET", "/internal", Problem{Type: "about:blank", Title: "Internal Server Error", Status: 500, Instance: "/internal"}},
+		{"GET", "/invalid", Problem{Type: "about:blank", Title: "Internal Server Error", Status: 500, Instance: "/invalid"}},
+		{"GET", "/missing", Problem{Type: "about:blank", Title: "Not Found", Status: 404, Detail: "no route matches /missing", Instance: "/missing"}},
+		{"DELETE", "/users/1", Problem{Type: "about:blank", Title: "Method Not Allowed", Status: 405, Detail: "method DELETE is not allowed for /users/1", Instance: "/users/1"}},
+	}
+	for _, c := range cases {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, httptest.NewRequest(c.method, c.path, nil))
+
+		var problem Problem
+		json.NewDecoder(res.Body).Decode(&problem)
+		if res.Code != c.expect.Status || res.Header().Get("Content-Type") != "application/problem+json" {
+			t.Fatalf("%s: unexpected response %d %q", c.path, res.Code, res.Header().Get("Content-Type"))
+		}
+		if fmt.Sprint(problem) != fmt.Sprint(c.expect) {
+			t.Fatalf("%s: expect %+v, but got %+v", c.path, c.expect, problem)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest("PUT", "/users", nil))
+	if res.Header().Get("Allow") != "POST" {
+		t.Fatalf("405 should list allowed methods, got %q", res.Header().Get("Allow"))
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest("POST", "/users", nil))
+	var problem Problem
+	json.NewDecoder(res.Body).Decode(&problem)
+	if res.Code != http.StatusBadRequest || len(problem.Errors) != 1 || problem.Errors[0].Field != "name" {
+		t.Fatalf("validation errors should be rendered, got %d %+v", res.Code, problem)
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest("GET", "/ok", nil))
+	if res.Body.String() != "ok" {
+		t.Fatalf("nil error should not be handled")
+	}
+}
+
+func TestCustomErrorHandler(t *testing.T) {
+	var recorded []error
+	engine := New()
+	engine.ErrorHandler = func(ctx *Context, err error) {
+		ctx.String(http.StatusTeapot, "custom: %v", err)
+	}
+	engine.Use(func(ctx *Context) {
+		ctx.Next()
+		recorded = ctx.Errors
+	})
+	engine.Get("/", E(func(ctx *Context) error {
+		return errors.New("boom")
+	}))
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest("GET", "/", nil))
+	if res.Code != http.StatusTeapot || res.Body.String() != "custom: boom" || len(recorded) != 1 {
+		t.Fatalf("custom error handler should be used, got %d %q", res.Code, res.Body.String())
+	}
+}
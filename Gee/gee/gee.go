@@ -2,9 +2,13 @@ package gee
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"text/template"
+
+	"golang.org/x/net/http2"
 )
 
 type Engine struct {
@@ -17,11 +21,32 @@ type Engine struct {
 	htmlTemplates *template.Template
 	// 自定义模板渲染函数，用于模板里的函数调用
 	funcMap template.FuncMap
+
+	// 可信代理，用于解析客户端IP
+	trustedProxies []*net.IPNet
+
+	// 统一处理 ctx.Error 与 HandlerFuncE 返回的错误，默认为 DefaultErrorHandler
+	ErrorHandler func(*Context, error)
+	// 路径存在但方法不匹配时返回 405 并设置 Allow 响应头，默认与其他未匹配的请求一样返回 404
+	HandleMethodNotAllowed bool
+
+	// 路由匹配之前执行的中间件
+	preMiddlewares []HandlerFunc
+	// 是否支持 HTTP/2 明文传输
+	h2c      bool
+	h2Server *http2.Server
+
+	// 已安装的插件与生命周期钩子
+	plugins []Plugin
+	hooks   hooks
+	// Run 启动的服务器，用于 Shutdown
+	server      *http.Server
+	serverMutex sync.Mutex
 }
 
 // 实例化一个Engine
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{router: newRouter(), ErrorHandler: DefaultErrorHandler}
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
 
@@ -37,44 +62,106 @@ func Default() *Engine {
 	return engine
 }
 
-func (engine *Engine) Get(pattern string, handler HandlerFunc) {
-	engine.router.addRoute("GET", pattern, handler)
+func (engine *Engine) Get(pattern string, handler HandlerFunc) *Route {
+	return engine.addRoute("GET", pattern, handler)
 }
 
-func (engine *Engine) Post(pattern string, handler HandlerFunc) {
-	engine.router.addRoute("POST", pattern, handler)
+func (engine *Engine) Post(pattern string, handler HandlerFunc) *Route {
+	return engine.addRoute("POST", pattern, handler)
 }
 
-// 开启一个http服务器，并传入engine实例实现的接口方法ServeHTTP
+// 开启一个http服务器，并传入engine实例实现的接口方法ServeHTTP，调用 Shutdown 后返回 nil
 func (engine *Engine) Run(addr string) error {
-	fmt.Printf("Server is running at http://127.0.0.1%v\n", addr)
-	return http.ListenAndServe(addr, engine)
+	server := &http.Server{Addr: addr, Handler: engine.Handler()}
+	engine.configureH2C(server)
+	return engine.serve(server, func() error {
+		fmt.Printf("Server is running at http://127.0.0.1%v\n", addr)
+		return server.ListenAndServe()
+	})
+}
+
+// 添加在路由匹配之前执行的中间件，可以修改请求的方法与路径
+func (engine *Engine) Pre(middlewares ...HandlerFunc) {
+	engine.preMiddlewares = append(engine.preMiddlewares, middlewares...)
 }
 
 // 真正的处理请求的地方
 func (engine *Engine) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	// 当来请求时，实例化一个Context
+	ctx := newContext(res, req)
+	ctx.engine = engine
+
+	if len(engine.preMiddlewares) == 0 {
+		engine.handle(ctx)
+		return
+	}
+
+	// 先执行前置中间件，最后再匹配路由
+	ctx.middlewares = make([]HandlerFunc, 0, len(engine.preMiddlewares)+1)
+	ctx.middlewares = append(ctx.middlewares, engine.preMiddlewares...)
+	ctx.middlewares = append(ctx.middlewares, engine.handle)
+	ctx.Next()
+}
+
+// 筛选分组中间件并匹配路由
+func (engine *Engine) handle(ctx *Context) {
+	// 前置中间件可能修改了请求
+	ctx.Method = ctx.Req.Method
+	// 路由会忽略空的路径段，分组中间件必须按同样的路径筛选，否则 //ops/debug 可以绕过 /ops 的中间件
+	ctx.Path = cleanPath(ctx.Req.URL.Path)
+
 	// 判断哪些中间件需要被执行
-	var middlewares []HandlerFunc
 	for _, group := range engine.groups {
 		// 筛选对应的中间件
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
+		if strings.HasPrefix(ctx.Path, group.prefix) {
+			ctx.middlewares = append(ctx.middlewares, group.middlewares...)
 		}
 	}
 
-	// 当来请求时，实例化一个Context
-	ctx := newContext(res, req)
-	ctx.middlewares = middlewares
-	ctx.engine = engine
 	engine.router.handler(ctx)
 }
 
+// 合并连续的 /，并确保以 / 开头
+func cleanPath(path string) string {
+	if !strings.Contains(path, "//") && strings.HasPrefix(path, "/") {
+		return path
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(path) + 1)
+	builder.WriteByte('/')
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if builder.Len() > 1 {
+			builder.WriteByte('/')
+		}
+		builder.WriteString(part)
+	}
+	// 保留结尾的 /
+	if strings.HasSuffix(path, "/") && builder.Len() > 1 {
+		builder.WriteByte('/')
+	}
+	return builder.String()
+}
+
 /* ------------------------------- HTML Render ------------------------------ */
 // 设置自定义渲染函数
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.funcMap = funcMap
 }
 
+// 追加自定义渲染函数，需要在 LoadHTMLGlob 之前调用
+func (engine *Engine) AddFuncMap(funcMap template.FuncMap) {
+	if engine.funcMap == nil {
+		engine.funcMap = make(template.FuncMap, len(funcMap))
+	}
+	for name, fn := range funcMap {
+		engine.funcMap[name] = fn
+	}
+}
+
 // 加载HTML模板
 func (engine *Engine) LoadHTMLGlob(templatePath string) {
 	// 实例化一个模板并将Funcs加入进去，并执行解析的模板文件夹
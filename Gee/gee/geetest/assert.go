@@ -0,0 +1,158 @@
+package geetest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/* ---------------------------------- 响应断言 ---------------------------------- */
+/**
+ * 断言失败时调用 t.Errorf，不中断测试，可以链式调用：
+ *  geetest.Expect(t, res).Status(200).Header("Content-Type", "application/json").JSON("data.users[0].name", "gee")
+ * JSON 路径以 . 分隔对象字段，[n] 或 .n 访问数组元素，空路径表示整个响应体
+ */
+
+type Assertion struct {
+	t   testing.TB
+	res *httptest.ResponseRecorder
+}
+
+func Expect(t testing.TB, res *httptest.ResponseRecorder) *Assertion {
+	return &Assertion{t: t, res: res}
+}
+
+func (assertion *Assertion) Status(code int) *Assertion {
+	assertion.t.Helper()
+	if assertion.res.Code != code {
+		assertion.t.Errorf("expect status %d, but got %d, body: %s", code, assertion.res.Code, assertion.res.Body.String())
+	}
+	return assertion
+}
+
+func (assertion *Assertion) Header(key string, value string) *Assertion {
+	assertion.t.Helper()
+	if got := assertion.res.Header().Get(key); got != value {
+		assertion.t.Errorf("expect header %s %q, but got %q", key, value, got)
+	}
+	return assertion
+}
+
+func (assertion *Assertion) HeaderContains(key string, value string) *Assertion {
+	assertion.t.Helper()
+	if got := assertion.res.Header().Get(key); !strings.Contains(got, value) {
+		assertion.t.Errorf("expect header %s to contain %q, but got %q", key, value, got)
+	}
+	return assertion
+}
+
+func (assertion *Assertion) Body(body string) *Assertion {
+	assertion.t.Helper()
+	if got := assertion.res.Body.String(); got != body {
+		assertion.t.Errorf("expect body %q, but got %q", body, got)
+	}
+	return assertion
+}
+
+func (assertion *Assertion) BodyContains(fragment string) *Assertion {
+	assertion.t.Helper()
+	if got := assertion.res.Body.String(); !strings.Contains(got, fragment) {
+		assertion.t.Errorf("expect body to contain %q, but got %q", fragment, got)
+	}
+	return assertion
+}
+
+// 断言 JSON 路径上的值，expect 先编码为 JSON 再比较，因此数字类型不需要与解码结果一致
+func (assertion *Assertion) JSON(path string, expect interface{}) *Assertion {
+	assertion.t.Helper()
+
+	got, err := assertion.lookup(path)
+	if err != "" {
+		assertion.t.Errorf("%s", err)
+		return assertion
+	}
+
+	var want interface{}
+	data, _ := json.Marshal(expect)
+	json.Unmarshal(data, &want)
+	if !reflect.DeepEqual(got, want) {
+		gotData, _ := json.Marshal(got)
+		assertion.t.Errorf("expect JSON %q to be %s, but got %s", path, data, gotData)
+	}
+	return assertion
+}
+
+// 断言 JSON 路径存在
+func (assertion *Assertion) JSONExists(path string) *Assertion {
+	assertion.t.Helper()
+	if _, err := assertion.lookup(path); err != "" {
+		assertion.t.Errorf("%s", err)
+	}
+	return assertion
+}
+
+// 断言响应为 HTML，且包含所有片段，比较前会合并连续的空白字符
+func (assertion *Assertion) HTML(fragments ...string) *Assertion {
+	assertion.t.Helper()
+
+	if contentType := assertion.res.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		assertion.t.Errorf("expect HTML response, but got Content-Type %q", contentType)
+	}
+	body := collapseSpace(assertion.res.Body.String())
+	for _, fragment := range fragments {
+		if !strings.Contains(body, collapseSpace(fragment)) {
+			assertion.t.Errorf("expect HTML to contain %q, but got %q", fragment, body)
+		}
+	}
+	return assertion
+}
+
+// 查找 JSON 路径上的值，失败时返回错误信息
+func (assertion *Assertion) lookup(path string) (interface{}, string) {
+	var value interface{}
+	if err := json.Unmarshal(assertion.res.Body.Bytes(), &value); err != nil {
+		return nil, "response is not JSON: " + err.Error()
+	}
+
+	for _, key := range splitPath(path) {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			v, ok := current[key]
+			if !ok {
+				return nil, "JSON path " + strconv.Quote(path) + " not found at " + strconv.Quote(key)
+			}
+			value = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil, "JSON path " + strconv.Quote(path) + " has invalid index " + strconv.Quote(key)
+			}
+			value = current[i]
+		default:
+			return nil, "JSON path " + strconv.Quote(path) + " not found at " + strconv.Quote(key)
+		}
+	}
+
+	return value, ""
+}
+
+// data.users[0].name -> [data users 0 name]
+func splitPath(path string) []string {
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	keys := make([]string, 0)
+	for _, key := range strings.Split(path, ".") {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
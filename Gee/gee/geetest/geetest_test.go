@@ -0,0 +1,118 @@
+package geetest
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newEngine(t *testing.T) *gee.Engine {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "user.tmpl"), []byte("<h1>\n  {{ .name }}\n</h1>"), 0644)
+
+	engine := gee.New()
+	engine.LoadHTMLGlob(filepath.Join(dir, "*"))
+	engine.Get("/users/:id", func(ctx *gee.Context) {
+		token, _ := ctx.Cookie("token")
+		ctx.SetHeader("X-Token", token)
+		ctx.JSON(http.StatusOK, gee.H{
+			"id":    ctx.Param("id"),
+			"query": ctx.Query("q"),
+			"roles": []gee.H{{"name": "admin", "level": 1}},
+		})
+	})
+	engine.Post("/users", func(ctx *gee.Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := ctx.BindJSON(&body); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.HTML(http.StatusCreated, "user.tmpl", gee.H{"name": body.Name + ctx.Req.Header.Get("X-Suffix")})
+	})
+	engine.Post("/form", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, ctx.PostForm("name"))
+	})
+	return engine
+}
+
+func TestRequestAndAssertions(t *testing.T) {
+	engine := newEngine(t)
+
+	res := engine.Test(Get("/users/1?x=1").Query("q", "gee").Cookie(&http.Cookie{Name: "token", Value: "secret"}).Build())
+	Expect(t, res).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/json").
+		Header("X-Token", "secret").
+		JSON("id", "1").
+		JSON("query", "gee").
+		JSON("roles[0].level", 1).
+		JSON("roles.0", gee.H{"name": "admin", "level": 1}).
+		JSONExists("roles")
+
+	res = engine.Test(Post("/users").JSON(gee.H{"name": "gee"}).Header("X-Suffix", "!").Build())
+	Expect(t, res).Status(http.StatusCreated).HTML("<h1> gee! </h1>")
+
+	res = engine.Test(Post("/form").Form(url.Values{"name": {"form"}}).Build())
+	Expect(t, res).Status(http.StatusOK).Body("form").BodyContains("or")
+}
+
+func TestFailedAssertions(t *testing.T) {
+	engine := newEngine(t)
+	res := engine.Test(Get("/users/1").Build())
+
+	mock := &mockT{TB: t}
+	Expect(mock, res).
+		Status(http.StatusNotFound).
+		Header("X-Token", "x").
+		JSON("roles[1]", nil).
+		JSON("id", 1).
+		JSONExists("missing").
+		HTML()
+	if mock.failures != 6 {
+		t.Fatalf("expect 6 failures, but got %d", mock.failures)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	next := false
+	ctx, res := NewContext(Get("/users/1").Build(), Preset{
+		Params: map[string]string{"id": "1"},
+		Keys:   map[string]interface{}{"user": "gee"},
+		Next: []gee.HandlerFunc{func(ctx *gee.Context) {
+			next = true
+		}},
+	})
+
+	handler := func(ctx *gee.Context) {
+		ctx.Next()
+		ctx.String(http.StatusOK, "%s %s", ctx.Param("id"), ctx.GetString("user"))
+	}
+	handler(ctx)
+
+	Expect(t, res).Status(http.StatusOK).Body("1 gee")
+	if !next {
+		t.Fatalf("preset next handlers should be called")
+	}
+
+	// 没有后续处理函数时，调用 Next 不会 panic
+	ctx, _ = NewContext(httptest.NewRequest(http.MethodGet, "/", nil), Preset{})
+	ctx.Next()
+}
+
+// 记录失败次数而不是让测试失败
+type mockT struct {
+	testing.TB
+	failures int
+}
+
+func (t *mockT) Helper() {}
+
+func (t *mockT) Errorf(format string, args ...interface{}) {
+	t.failures++
+}
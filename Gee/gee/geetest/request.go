@@ -0,0 +1,145 @@
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"gee-demo/gee"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+)
+
+/* ---------------------------------- 请求构造 ---------------------------------- */
+/**
+ * 链式构造测试请求：
+ *  req := geetest.Post("/users").Query("dry", "1").JSON(gee.H{"name": "gee"}).Header("X-Token", "t").Build()
+ *  res := engine.Test(req)
+ */
+
+type RequestBuilder struct {
+	method  string
+	path    string
+	query   url.Values
+	header  http.Header
+	cookies []*http.Cookie
+	body    io.Reader
+}
+
+func NewRequest(method string, path string) *RequestBuilder {
+	return &RequestBuilder{
+		method: method,
+		path:   path,
+		query:  make(url.Values),
+		header: make(http.Header),
+	}
+}
+
+func Get(path string) *RequestBuilder {
+	return NewRequest(http.MethodGet, path)
+}
+
+func Post(path string) *RequestBuilder {
+	return NewRequest(http.MethodPost, path)
+}
+
+// 追加 Query 参数
+func (builder *RequestBuilder) Query(key string, value string) *RequestBuilder {
+	builder.query.Add(key, value)
+	return builder
+}
+
+// 设置请求头
+func (builder *RequestBuilder) Header(key string, value string) *RequestBuilder {
+	builder.header.Set(key, value)
+	return builder
+}
+
+func (builder *RequestBuilder) Cookie(cookie *http.Cookie) *RequestBuilder {
+	builder.cookies = append(builder.cookies, cookie)
+	return builder
+}
+
+// 以 JSON 编码请求体，编码失败时 panic
+func (builder *RequestBuilder) JSON(obj interface{}) *RequestBuilder {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		panic("geetest: " + err.Error())
+	}
+	return builder.Body("application/json", string(data))
+}
+
+// 表单请求体
+func (builder *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	return builder.Body("application/x-www-form-urlencoded", values.Encode())
+}
+
+// 指定类型的请求体
+func (builder *RequestBuilder) Body(contentType string, body string) *RequestBuilder {
+	builder.header.Set("Content-Type", contentType)
+	builder.body = strings.NewReader(body)
+	return builder
+}
+
+// 构造请求，path 中已有的 Query 参数会被保留
+func (builder *RequestBuilder) Build() *http.Request {
+	body := builder.body
+	if body == nil {
+		body = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(builder.method, builder.path, body)
+	if len(builder.query) > 0 {
+		query := req.URL.Query()
+		for key, values := range builder.query {
+			query[key] = append(query[key], values...)
+		}
+		req.URL.RawQuery = query.Encode()
+		req.RequestURI = req.URL.RequestURI()
+	}
+	for key, values := range builder.header {
+		req.Header[key] = values
+	}
+	for _, cookie := range builder.cookies {
+		req.AddCookie(cookie)
+	}
+
+	return req
+}
+
+/* ---------------------------------- Context ---------------------------------- */
+
+// 预设的 Context 数据
+type Preset struct {
+	// 默认 gee.New()，渲染 HTML 时需要设置已加载模板的 Engine
+	Engine *gee.Engine
+	// 路由参数
+	Params map[string]string
+	// 通过 ctx.Set 设置的键值
+	Keys map[string]interface{}
+	// 调用 ctx.Next() 时依次执行的处理函数
+	Next []gee.HandlerFunc
+}
+
+// 实例化一个 Context，用于单独测试某个 HandlerFunc
+//
+//	ctx, res := geetest.NewContext(geetest.Get("/users/1").Build(), geetest.Preset{Params: map[string]string{"id": "1"}})
+//	getUser(ctx)
+func NewContext(req *http.Request, preset Preset) (*gee.Context, *httptest.ResponseRecorder) {
+	engine := preset.Engine
+	if engine == nil {
+		engine = gee.New()
+	}
+
+	res := httptest.NewRecorder()
+	ctx := engine.NewContext(res, req, preset.Next...)
+	for key, value := range preset.Params {
+		ctx.Params[key] = value
+	}
+	for key, value := range preset.Keys {
+		ctx.Set(key, value)
+	}
+
+	return ctx, res
+}
@@ -0,0 +1,50 @@
+package gee
+
+import (
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+/* ---------------------------------- HTTP/2 明文传输 ---------------------------------- */
+/**
+ * 开启 h2c 后，客户端可以不使用 TLS 直接以 HTTP/2 通信（prior knowledge 或 Upgrade: h2c）
+ * HTTP/2 的请求体与响应可以同时读写，流式处理时每次写入后调用 Flush 即可实现全双工
+ */
+
+// 开启 HTTP/2 明文传输，需要在 Run 或 Handler 之前调用
+func (engine *Engine) UseH2C() {
+	engine.h2c = true
+}
+
+// 开启 h2c 并启动服务器
+func (engine *Engine) RunH2C(addr string) error {
+	engine.UseH2C()
+	return engine.Run(addr)
+}
+
+// 返回用于 http.Server 的 Handler，开启 h2c 时包装为 h2c Handler
+func (engine *Engine) Handler() http.Handler {
+	if !engine.h2c {
+		return engine
+	}
+	return h2c.NewHandler(engine, engine.http2Server())
+}
+
+func (engine *Engine) http2Server() *http2.Server {
+	engine.serverMutex.Lock()
+	defer engine.serverMutex.Unlock()
+
+	if engine.h2Server == nil {
+		engine.h2Server = &http2.Server{}
+	}
+	return engine.h2Server
+}
+
+// h2c 连接被 Hijack，需要注册到 http.Server 才能在 Shutdown 时优雅关闭
+func (engine *Engine) configureH2C(server *http.Server) {
+	if engine.h2c {
+		http2.ConfigureServer(server, engine.http2Server())
+	}
+}
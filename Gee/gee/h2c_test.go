@@ -0,0 +1,79 @@
+package gee
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2C(t *testing.T) {
+	engine := New()
+	engine.UseH2C()
+	engine.Get("/proto", func(ctx *Context) {
+		ctx.String(http.StatusOK, ctx.Req.Proto)
+	})
+	// 全双工：每读到一行就立即回写
+	engine.Post("/echo", func(ctx *Context) {
+		ctx.Status(http.StatusOK)
+		ctx.Res.(http.Flusher).Flush()
+		scanner := bufio.NewScanner(ctx.Req.Body)
+		for scanner.Scan() {
+			fmt.Fprintf(ctx.Res, "echo %s\n", scanner.Text())
+			ctx.Res.(http.Flusher).Flush()
+		}
+	})
+
+	server := httptest.NewServer(engine.Handler())
+	defer server.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+
+	res, err := client.Get(server.URL + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "HTTP/2.0" {
+		t.Fatalf("expect HTTP/2.0, but got %q", body)
+	}
+
+	reader, writer := io.Pipe()
+	res, err = client.Post(server.URL+"/echo", "text/plain", reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	lines := bufio.NewReader(res.Body)
+	for _, word := range []string{"ping", "pong"} {
+		// 在请求体结束之前就能读到响应
+		fmt.Fprintln(writer, word)
+		line, err := lines.ReadString('\n')
+		if err != nil || strings.TrimSpace(line) != "echo "+word {
+			t.Fatalf("expect echo %s, but got %q %v", word, line, err)
+		}
+	}
+	writer.Close()
+
+	// 未开启时仍然是 HTTP/1.1
+	plain := httptest.NewServer(New().Handler())
+	defer plain.Close()
+	if _, err := client.Get(plain.URL); err == nil {
+		t.Fatalf("h2c should not be accepted without UseH2C")
+	}
+}
@@ -0,0 +1,327 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"gee-demo/gee"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ---------------------------------- 响应缓存 ---------------------------------- */
+/**
+ * 缓冲 GET/HEAD 请求的响应：
+ *  为 200 响应计算强 ETag，请求的 If-None-Match 匹配时返回 304
+ *  使用 New 时，将完整的响应(状态码、响应头、响应体)缓存在带 TTL 的 LRU 中，
+ *  key 由方法、路径、Query 以及响应 Vary 指定的请求头组成，可以通过 Purge 按路由 pattern 清除
+ * 以下情况不缓存：请求带有 Authorization，响应 Cache-Control 为 no-store/private，响应设置了 Cookie，Vary: *
+ * 只缓存 handler(及内层中间件)写入的响应头，外层中间件设置的响应头、Set-Cookie 以及请求ID等每个请求不同的响应头不会被重放
+ * 调用 Flush 的流式响应直接输出，既不计算 ETag 也不缓存
+ */
+
+// 每个请求都不同的响应头，不缓存
+var volatileHeaders = []string{"Set-Cookie", gee.RequestIDHeader, "Traceparent", "Tracestate", "Date", "Age", "X-Cache"}
+
+type Config struct {
+	// 缓存时间，默认 1 分钟
+	TTL time.Duration
+	// 最多缓存的响应数量，默认 1000
+	Capacity int
+}
+
+type Cache struct {
+	config Config
+	// 为 nil 时只计算 ETag
+	store *store
+	now   func() time.Time
+}
+
+// 只计算 ETag 并处理 If-None-Match，不缓存响应
+func ETag() gee.HandlerFunc {
+	return (&Cache{now: time.Now}).Middleware()
+}
+
+// 根据配置实例化一个响应缓存
+func New(config Config) *Cache {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if config.Capacity <= 0 {
+		config.Capacity = 1000
+	}
+
+	return &Cache{config: config, store: newStore(config.Capacity), now: time.Now}
+}
+
+// 清除某个路由 pattern 下缓存的响应，如 /users/:id，返回清除的数量
+func (cache *Cache) Purge(pattern string) int {
+	if cache.store == nil {
+		return 0
+	}
+	return cache.store.purge(pattern)
+}
+
+// 清除所有缓存的响应
+func (cache *Cache) PurgeAll() {
+	if cache.store != nil {
+		cache.store.purgeAll()
+	}
+}
+
+// 缓存的响应数量
+func (cache *Cache) Len() int {
+	if cache.store == nil {
+		return 0
+	}
+	return cache.store.len()
+}
+
+func (cache *Cache) Middleware() gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		if ctx.Method != http.MethodGet && ctx.Method != http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		useStore := cache.store != nil && ctx.Req.Header.Get("Authorization") == ""
+		base := baseKey(ctx.Req)
+		if useStore && !hasDirective(ctx.Req.Header.Get("Cache-Control"), "no-cache", "no-store") {
+			key := fullKey(base, cache.store.vary(base), ctx.Req)
+			if e := cache.store.get(key, cache.now()); e != nil {
+				cache.serve(ctx, e)
+				return
+			}
+		}
+
+		// 记录外层中间件已设置的响应头，用于区分 handler 写入的响应头
+		before := ctx.Res.Header().Clone()
+		writer := &bufferWriter{ResponseWriter: ctx.Res}
+		ctx.Res = writer
+		defer func() {
+			ctx.Res = writer.ResponseWriter
+		}()
+
+		ctx.Next()
+
+		ctx.Res = writer.ResponseWriter
+		if writer.streaming {
+			return
+		}
+
+		status := writer.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		header := ctx.Res.Header()
+		if status == http.StatusOK && header.Get("ETag") == "" {
+			header.Set("ETag", strongETag(writer.buf.Bytes()))
+		}
+
+		if useStore {
+			header.Set("X-Cache", "MISS")
+			if vary, ok := cacheable(status, header); ok {
+				now := cache.now()
+				cache.store.add(vary, &entry{
+					key:     fullKey(base, vary, ctx.Req),
+					base:    base,
+					pattern: ctx.Pattern,
+					status:  status,
+					header:  handlerHeader(before, header),
+					body:    append([]byte(nil), writer.buf.Bytes()...),
+					etag:    header.Get("ETag"),
+					created: now,
+					expires: now.Add(cache.config.TTL),
+				})
+			}
+		}
+
+		if status == http.StatusOK && notModified(ctx.Req, header.Get("ETag")) {
+			writeNotModified(ctx)
+			return
+		}
+		ctx.Status(status)
+		ctx.Res.Write(writer.buf.Bytes())
+	}
+}
+
+// 使用缓存的响应，不覆盖当前请求已设置的响应头
+func (cache *Cache) serve(ctx *gee.Context, e *entry) {
+	header := ctx.Res.Header()
+	for key, values := range e.header {
+		if _, ok := header[key]; !ok {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+	header.Set("X-Cache", "HIT")
+	header.Set("Age", strconv.Itoa(int(cache.now().Sub(e.created).Seconds())))
+
+	if notModified(ctx.Req, e.etag) {
+		writeNotModified(ctx)
+		return
+	}
+	ctx.Status(e.status)
+	if ctx.Method != http.MethodHead {
+		ctx.Res.Write(e.body)
+	}
+}
+
+func writeNotModified(ctx *gee.Context) {
+	header := ctx.Res.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	ctx.Status(http.StatusNotModified)
+}
+
+// handler 新增或修改的响应头，去掉每个请求都不同的响应头
+func handlerHeader(before, after http.Header) http.Header {
+	header := make(http.Header)
+	for key, values := range after {
+		if !equalValues(before[key], values) {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+	for _, key := range volatileHeaders {
+		header.Del(key)
+	}
+	return header
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 响应是否可以缓存，返回排序后的 Vary 头
+func cacheable(status int, header http.Header) ([]string, bool) {
+	if status != http.StatusOK || header.Get("Set-Cookie") != "" {
+		return nil, false
+	}
+	if hasDirective(header.Get("Cache-Control"), "no-store", "private") {
+		return nil, false
+	}
+
+	vary := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				vary = append(vary, name)
+			}
+		}
+	}
+	sort.Strings(vary)
+
+	return vary, true
+}
+
+// 方法 + 路径 + 排序后的 Query
+func baseKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode()
+}
+
+// 基础 key + Vary 指定的请求头
+func fullKey(base string, vary []string, req *http.Request) string {
+	var builder strings.Builder
+	builder.WriteString(base)
+	for _, name := range vary {
+		builder.WriteString("\n" + name + ":" + strings.Join(req.Header.Values(name), ","))
+	}
+	return builder.String()
+}
+
+// 响应体的 SHA-256 前 16 字节
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// If-None-Match 使用弱比较
+func notModified(req *http.Request, etag string) bool {
+	ifNoneMatch := req.Header.Get("If-None-Match")
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache-Control 是否包含任一指令
+func hasDirective(cacheControl string, directives ...string) bool {
+	for _, part := range strings.Split(cacheControl, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		for _, directive := range directives {
+			if strings.EqualFold(name, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/* ------------------------------ 缓冲 ResponseWriter ----------------------------- */
+
+type bufferWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+	// 调用过 Flush，之后直接输出
+	streaming bool
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+// 实现 http.Flusher，输出缓冲的数据并切换为直接输出
+func (w *bufferWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		if w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+		w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,173 @@
+package httpcache
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func get(engine *gee.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+func TestETag(t *testing.T) {
+	engine := gee.New()
+	engine.Use(ETag())
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "hello")
+	})
+	engine.Get("/missing", func(ctx *gee.Context) {
+		ctx.String(http.StatusNotFound, "missing")
+	})
+
+	res := get(engine, "/", nil)
+	etag := res.Header().Get("ETag")
+	if res.Code != http.StatusOK || res.Body.String() != "hello" || len(etag) != 34 {
+		t.Fatalf("unexpected response %d %q %q", res.Code, res.Body.String(), etag)
+	}
+
+	res = get(engine, "/", map[string]string{"If-None-Match": `"other", W/` + etag})
+	if res.Code != http.StatusNotModified || res.Body.Len() != 0 || res.Header().Get("ETag") != etag {
+		t.Fatalf("matching etag should get 304, got %d", res.Code)
+	}
+
+	res = get(engine, "/missing", nil)
+	if res.Code != http.StatusNotFound || res.Header().Get("ETag") != "" || res.Body.String() != "missing" {
+		t.Fatalf("non-200 responses should not have etag")
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	cache := New(Config{TTL: time.Minute, Capacity: 10})
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	engine := gee.New()
+	engine.Use(cache.Middleware())
+	engine.Get("/users/:id", func(ctx *gee.Context) {
+		calls++
+		ctx.SetHeader("Vary", "Accept-Language")
+		ctx.String(http.StatusOK, "user %s %s %d", ctx.Param("id"), ctx.Req.Header.Get("Accept-Language"), calls)
+	})
+	engine.Get("/private", func(ctx *gee.Context) {
+		calls++
+		ctx.SetHeader("Cache-Control", "private")
+		ctx.String(http.StatusOK, "private")
+	})
+
+	res := get(engine, "/users/1?b=2&a=1", map[string]string{"Accept-Language": "en"})
+	if res.Header().Get("X-Cache") != "MISS" || res.Body.String() != "user 1 en 1" {
+		t.Fatalf("first request should miss, got %q", res.Body.String())
+	}
+
+	// query 顺序不影响 key
+	res = get(engine, "/users/1?a=1&b=2", map[string]string{"Accept-Language": "en"})
+	if res.Header().Get("X-Cache") != "HIT" || res.Body.String() != "user 1 en 1" {
+		t.Fatalf("second request should hit, got %q", res.Body.String())
+	}
+
+	// Vary 的请求头不同
+	res = get(engine, "/users/1?a=1&b=2", map[string]string{"Accept-Language": "zh"})
+	if res.Header().Get("X-Cache") != "MISS" || res.Body.String() != "user 1 zh 2" {
+		t.Fatalf("different vary header should miss, got %q", res.Body.String())
+	}
+
+	// 缓存命中时同样处理 If-None-Match
+	res = get(engine, "/users/1?a=1&b=2", map[string]string{"Accept-Language": "zh", "If-None-Match": res.Header().Get("ETag")})
+	if res.Code != http.StatusNotModified || res.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("cached response should be revalidated, got %d", res.Code)
+	}
+
+	get(engine, "/users/2", nil)
+	if n := cache.Purge("/users/:id"); n != 3 || cache.Len() != 0 {
+		t.Fatalf("purge should remove 3 entries, got %d, left %d", n, cache.Len())
+	}
+
+	get(engine, "/private", nil)
+	get(engine, "/private", nil)
+	if cache.Len() != 0 {
+		t.Fatalf("private responses should not be cached")
+	}
+
+	// 过期
+	calls = 0
+	get(engine, "/users/3", nil)
+	now = now.Add(2 * time.Minute)
+	res = get(engine, "/users/3", nil)
+	if res.Header().Get("X-Cache") != "MISS" || calls != 2 {
+		t.Fatalf("expired response should not be used")
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	cache := New(Config{})
+	engine := gee.New()
+	engine.Use(cache.Middleware())
+	engine.Get("/stream", func(ctx *gee.Context) {
+		ctx.Res.Write([]byte("a"))
+		ctx.Res.(http.Flusher).Flush()
+		ctx.Res.Write([]byte("b"))
+	})
+
+	res := get(engine, "/stream", nil)
+	if res.Body.String() != "ab" || !res.Flushed || res.Header().Get("ETag") != "" || cache.Len() != 0 {
+		t.Fatalf("streaming response should be passed through")
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	cache := New(Config{})
+	engine := gee.New()
+	engine.Use(gee.RequestID(), func(ctx *gee.Context) {
+		ctx.SetHeader("X-Outer", "outer")
+		ctx.Next()
+	}, cache.Middleware())
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.SetHeader("X-Handler", "handler")
+		ctx.String(http.StatusOK, "hello")
+	})
+
+	first := get(engine, "/", nil)
+	second := get(engine, "/", nil)
+	if second.Header().Get("X-Cache") != "HIT" || second.Header().Get("X-Handler") != "handler" {
+		t.Fatalf("handler headers should be replayed, got %v", second.Header())
+	}
+	id := second.Header().Get(gee.RequestIDHeader)
+	if id == "" || id == first.Header().Get(gee.RequestIDHeader) {
+		t.Fatalf("request id should not be replayed from cache, got %q", id)
+	}
+	if len(second.Header().Values(gee.RequestIDHeader)) != 1 || len(second.Header().Values("X-Outer")) != 1 {
+		t.Fatalf("headers set by outer middlewares should not be duplicated, got %v", second.Header())
+	}
+}
+
+func TestStoreVaries(t *testing.T) {
+	cache := New(Config{Capacity: 2})
+	engine := gee.New()
+	engine.Use(cache.Middleware())
+	engine.Get("/items/:id", func(ctx *gee.Context) {
+		ctx.SetHeader("Vary", "Accept-Language")
+		ctx.String(http.StatusOK, "item %s", ctx.Param("id"))
+	})
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		get(engine, "/items/"+id, nil)
+	}
+	if n := len(cache.store.varies); n != 2 {
+		t.Fatalf("vary list of evicted responses should be removed, got %d", n)
+	}
+
+	cache.Purge("/items/:id")
+	if n := len(cache.store.varies); n != 0 {
+		t.Fatalf("vary list of purged responses should be removed, got %d", n)
+	}
+}
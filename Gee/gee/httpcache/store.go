@@ -0,0 +1,157 @@
+package httpcache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 响应存储 ---------------------------------- */
+/**
+ * 带有过期时间的 LRU，超出容量时淘汰最久未使用的响应，过期的响应在读取时惰性删除
+ * 同时按路由 pattern 建立索引，用于 Purge
+ */
+
+// 缓存的响应
+type entry struct {
+	key string
+	// 不含 Vary 请求头的基础 key
+	base    string
+	pattern string
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	created time.Time
+	expires time.Time
+}
+
+type store struct {
+	mutex    sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	// pattern -> key 集合
+	patterns map[string]map[string]struct{}
+	// 基础 key -> 响应的 Vary 头，用于计算完整的 key
+	varies map[string]*variants
+}
+
+// 同一基础 key 的响应
+type variants struct {
+	vary []string
+	// 缓存的响应数量，为 0 时删除
+	entries int
+}
+
+func newStore(capacity int) *store {
+	return &store{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		patterns: make(map[string]map[string]struct{}),
+		varies:   make(map[string]*variants),
+	}
+}
+
+func (s *store) vary(base string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if v := s.varies[base]; v != nil {
+		return v.vary
+	}
+	return nil
+}
+
+func (s *store) get(key string, now time.Time) *entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	e := element.Value.(*entry)
+	if !now.Before(e.expires) {
+		s.removeElement(element)
+		return nil
+	}
+	s.ll.MoveToFront(element)
+	return e
+}
+
+func (s *store) add(vary []string, e *entry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.items[e.key]; ok {
+		s.removeElement(element)
+	}
+
+	v := s.varies[e.base]
+	if v == nil {
+		v = &variants{}
+		s.varies[e.base] = v
+	}
+	v.vary = vary
+	v.entries++
+
+	s.items[e.key] = s.ll.PushFront(e)
+	if s.patterns[e.pattern] == nil {
+		s.patterns[e.pattern] = make(map[string]struct{})
+	}
+	s.patterns[e.pattern][e.key] = struct{}{}
+
+	for s.ll.Len() > s.capacity {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+// 删除某个路由 pattern 下的所有响应
+func (s *store) purge(pattern string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	keys := s.patterns[pattern]
+	// removeElement 会修改 keys，先记录数量
+	n := len(keys)
+	for key := range keys {
+		s.removeElement(s.items[key])
+	}
+	return n
+}
+
+func (s *store) purgeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ll.Init()
+	s.items = make(map[string]*list.Element)
+	s.patterns = make(map[string]map[string]struct{})
+	s.varies = make(map[string]*variants)
+}
+
+func (s *store) len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ll.Len()
+}
+
+func (s *store) removeElement(element *list.Element) {
+	e := element.Value.(*entry)
+	s.ll.Remove(element)
+	delete(s.items, e.key)
+	if keys := s.patterns[e.pattern]; keys != nil {
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(s.patterns, e.pattern)
+		}
+	}
+	if v := s.varies[e.base]; v != nil {
+		v.entries--
+		if v.entries == 0 {
+			delete(s.varies, e.base)
+		}
+	}
+}
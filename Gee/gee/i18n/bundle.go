@@ -0,0 +1,191 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"gee-demo/gee"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/* ---------------------------------- 文本目录 ---------------------------------- */
+/**
+ * 每个文件对应一种语言，文件名即语言标签，如 en.json、zh-CN.yaml、fr.toml
+ * 嵌套的对象展开为以 . 分隔的 key：
+ *  validation:
+ *    required: "不能为空"
+ * 对应 key validation.required
+ * 查找顺序：zh-CN -> zh -> 默认语言
+ */
+
+// 按扩展名解析文件
+var unmarshalers = map[string]func([]byte, interface{}) error{
+	".json": json.Unmarshal,
+	".yaml": yaml.Unmarshal,
+	".yml":  yaml.Unmarshal,
+	".toml": toml.Unmarshal,
+}
+
+type Bundle struct {
+	defaultLocale string
+
+	mutex sync.RWMutex
+	// 规范化的语言标签 -> key -> 文本
+	catalogs map[string]map[string]string
+	// 规范化的语言标签 -> 原始的语言标签
+	names map[string]string
+}
+
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]map[string]string),
+		names:         make(map[string]string),
+	}
+}
+
+// 加载 root 目录下所有 json、yaml、toml 文件
+//
+//	//go:embed locales
+//	var locales embed.FS
+//	bundle.LoadFS(locales, "locales")
+func (bundle *Bundle) LoadFS(fsys fs.FS, root string) error {
+	return fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		ext := path.Ext(name)
+		unmarshal, ok := unmarshalers[ext]
+		if !ok {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var messages map[string]interface{}
+		if err := unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: %s: %w", name, err)
+		}
+
+		bundle.AddMessages(strings.TrimSuffix(path.Base(name), ext), messages)
+		return nil
+	})
+}
+
+// 添加某个语言的文本，值可以是嵌套的 map
+func (bundle *Bundle) AddMessages(locale string, messages map[string]interface{}) {
+	bundle.mutex.Lock()
+	defer bundle.mutex.Unlock()
+
+	tag := normalize(locale)
+	catalog, ok := bundle.catalogs[tag]
+	if !ok {
+		catalog = make(map[string]string)
+		bundle.catalogs[tag] = catalog
+		bundle.names[tag] = locale
+	}
+	flatten("", messages, catalog)
+}
+
+// 已加载的语言
+func (bundle *Bundle) Locales() []string {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	locales := make([]string, 0, len(bundle.names))
+	for _, name := range bundle.names {
+		locales = append(locales, name)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// 查找与语言标签最接近的已加载语言，没有时返回空字符串
+func (bundle *Bundle) Match(locale string) string {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	tag := normalize(locale)
+	if name, ok := bundle.names[tag]; ok {
+		return name
+	}
+	base := baseOf(tag)
+	if name, ok := bundle.names[base]; ok {
+		return name
+	}
+	// 同一语言的其他地区，如 zh-TW 匹配 zh-CN
+	for _, candidate := range sortedKeys(bundle.names) {
+		if baseOf(candidate) == base {
+			return bundle.names[candidate]
+		}
+	}
+	return ""
+}
+
+// 查找文本，依次尝试 locale、locale 的语言部分与默认语言
+func (bundle *Bundle) Lookup(locale, key string) (string, bool) {
+	bundle.mutex.RLock()
+	defer bundle.mutex.RUnlock()
+
+	tag, fallback := normalize(locale), normalize(bundle.defaultLocale)
+	for _, candidate := range []string{tag, baseOf(tag), fallback, baseOf(fallback)} {
+		if message, ok := bundle.catalogs[candidate][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// 在请求之外翻译文本，如发送邮件，不存在时返回 key
+func (bundle *Bundle) Translate(locale, key string, args ...interface{}) string {
+	message, ok := bundle.Lookup(locale, key)
+	if !ok {
+		message = key
+	}
+	return gee.FormatMessage(message, args...)
+}
+
+// 将嵌套的对象展开为 a.b.c 形式的 key
+func flatten(prefix string, value interface{}, catalog map[string]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, child, catalog)
+		}
+	case string:
+		catalog[prefix] = value
+	default:
+		catalog[prefix] = fmt.Sprint(value)
+	}
+}
+
+// zh_CN、ZH-cn 统一为 zh-cn
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// zh-cn -> zh
+func baseOf(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"gee-demo/gee"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+/* ---------------------------------- 国际化 ---------------------------------- */
+/**
+ * 按以下顺序确定请求的语言：
+ *  1. Query 参数：?lang=zh-CN
+ *  2. Cookie：lang=zh-CN
+ *  3. Accept-Language 请求头，按权重 q 从高到低匹配
+ *  4. Bundle 的默认语言
+ * 确定后设置 gee.Translator，ctx.T、ctx.FatalT、框架中间件的错误信息、404 与参数校验的错误信息都会使用该语言
+ */
+
+// 当前请求的语言在 ctx 中的 key
+const LocaleKey = "gee.locale"
+
+type Config struct {
+	// 文本目录
+	Bundle *Bundle
+	// 指定语言的 Query 参数，默认 lang
+	QueryParam string
+	// 指定语言的 Cookie，默认 lang
+	CookieName string
+}
+
+// 根据配置实例化一个国际化中间件
+//
+//	bundle := i18n.NewBundle("en")
+//	bundle.LoadFS(locales, "locales")
+//	router.Use(i18n.New(i18n.Config{Bundle: bundle}))
+func New(config Config) gee.HandlerFunc {
+	if config.Bundle == nil {
+		panic("i18n: bundle is required")
+	}
+	if config.QueryParam == "" {
+		config.QueryParam = "lang"
+	}
+	if config.CookieName == "" {
+		config.CookieName = "lang"
+	}
+	bundle := config.Bundle
+
+	return func(ctx *gee.Context) {
+		locale := config.resolve(ctx)
+
+		ctx.Set(LocaleKey, locale)
+		ctx.Set(gee.TranslatorKey, gee.Translator(func(key string) (string, bool) {
+			return bundle.Lookup(locale, key)
+		}))
+		ctx.SetTemplateFunc("t", ctx.T)
+		ctx.SetTemplateFunc("locale", func() string { return locale })
+
+		header := ctx.Res.Header()
+		header.Set("Content-Language", locale)
+		header.Add("Vary", "Accept-Language")
+
+		ctx.Next()
+	}
+}
+
+// 获取当前请求的语言
+func Locale(ctx *gee.Context) string {
+	return ctx.GetString(LocaleKey)
+}
+
+// 模板函数，需要在 LoadHTMLGlob 之前注册，实际的值由中间件按请求设置
+//
+//	router.AddFuncMap(i18n.FuncMap())
+//	<h1>{{ t "welcome" .User }}</h1>
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"t":      func(key string, args ...interface{}) string { return key },
+		"locale": func() string { return "" },
+	}
+}
+
+func (config *Config) resolve(ctx *gee.Context) string {
+	bundle := config.Bundle
+
+	if locale := bundle.Match(ctx.Query(config.QueryParam)); locale != "" {
+		return locale
+	}
+	if value, err := ctx.Cookie(config.CookieName); err == nil {
+		if locale := bundle.Match(value); locale != "" {
+			return locale
+		}
+	}
+	for _, tag := range acceptLanguages(ctx.GetHeader("Accept-Language")) {
+		if locale := bundle.Match(tag); locale != "" {
+			return locale
+		}
+	}
+
+	return bundle.defaultLocale
+}
+
+// 解析 Accept-Language，按权重从高到低返回语言标签，忽略 * 与 q=0
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+
+	languages := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = value
+		}
+		if weight > 0 {
+			languages = append(languages, weighted{tag: tag, weight: weight})
+		}
+	}
+
+	// 权重相同时保持原有顺序
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].weight > languages[j].weight
+	})
+
+	tags := make([]string, len(languages))
+	for i, language := range languages {
+		tags[i] = language.tag
+	}
+	return tags
+}
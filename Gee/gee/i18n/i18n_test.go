@@ -0,0 +1,199 @@
+package i18n
+
+import (
+	"encoding/json"
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var locales = fstest.MapFS{
+	"locales/en.json": {Data: []byte(`{"greeting": "Hello, {name}", "items": "%d items"}`)},
+	"locales/zh-CN.yaml": {Data: []byte(`
+greeting: "你好，{name}"
+gee:
+  not_found: "找不到页面：{path}"
+  internal_error: "服务器内部错误"
+validation:
+  required: "不能为空"
+  min_length: "长度不能少于 {param}"
+Bad Request: 请求错误
+validation failed: 参数校验失败
+`)},
+	"locales/fr.toml": {Data: []byte(`
+greeting = "Bonjour, {name}"
+[validation]
+required = "est obligatoire"
+`)},
+	"locales/README.md": {Data: []byte(`ignored`)},
+}
+
+func newBundle(t *testing.T) *Bundle {
+	bundle := NewBundle("en")
+	if err := bundle.LoadFS(locales, "locales"); err != nil {
+		t.Fatalf("load locales: %v", err)
+	}
+	return bundle
+}
+
+func TestBundle(t *testing.T) {
+	bundle := newBundle(t)
+
+	if locales := bundle.Locales(); len(locales) != 3 || locales[0] != "en" || locales[2] != "zh-CN" {
+		t.Fatalf("unexpected locales %v", locales)
+	}
+	cases := map[string]string{"zh_cn": "zh-CN", "zh-TW": "zh-CN", "zh": "zh-CN", "fr-CA": "fr", "de": ""}
+	for tag, expect := range cases {
+		if got := bundle.Match(tag); got != expect {
+			t.Fatalf("match %s: expect %q, but got %q", tag, expect, got)
+		}
+	}
+
+	if got := bundle.Translate("zh-CN", "validation.required"); got != "不能为空" {
+		t.Fatalf("nested keys should be flattened, got %q", got)
+	}
+	// 缺失的 key 回退到默认语言
+	if got := bundle.Translate("fr", "items", 3); got != "3 items" {
+		t.Fatalf("missing key should fall back to the default locale, got %q", got)
+	}
+	if got := bundle.Translate("fr", "missing"); got != "missing" {
+		t.Fatalf("unknown key should be returned as is, got %q", got)
+	}
+
+	if err := NewBundle("en").LoadFS(fstest.MapFS{"en.json": {Data: []byte(`{`)}}, "."); err == nil {
+		t.Fatalf("invalid file should fail to load")
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	engine := gee.New()
+	engine.Use(New(Config{Bundle: newBundle(t)}))
+	engine.Get("/hello", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "%s %s", Locale(ctx), ctx.T("greeting", gee.H{"name": "Gee"}))
+	})
+
+	cases := []struct {
+		url    string
+		cookie string
+		accept string
+		expect string
+	}{
+		{"/hello", "", "", "en Hello, Gee"},
+		{"/hello", "", "de, fr;q=0.8, zh-CN;q=0.9", "zh-CN 你好，Gee"},
+		{"/hello", "", "zh-CN;q=0, fr", "fr Bonjour, Gee"},
+		{"/hello", "lang=fr", "zh-CN", "fr Bonjour, Gee"},
+		{"/hello?lang=zh-CN", "lang=fr", "en", "zh-CN 你好，Gee"},
+		// 不支持的语言被忽略
+		{"/hello?lang=de", "", "fr", "fr Bonjour, Gee"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.url, nil)
+		if c.cookie != "" {
+			req.Header.Set("Cookie", c.cookie)
+		}
+		if c.accept != "" {
+			req.Header.Set("Accept-Language", c.accept)
+		}
+		res := engine.Test(req)
+		if res.Body.String() != c.expect {
+			t.Fatalf("%s %q %q: expect %q, but got %q", c.url, c.cookie, c.accept, c.expect, res.Body.String())
+		}
+		if res.Header().Get("Vary") != "Accept-Language" {
+			t.Fatalf("response should vary by Accept-Language")
+		}
+	}
+}
+
+type signup struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"min=6"`
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	engine := gee.New()
+	engine.Use(New(Config{Bundle: newBundle(t)}))
+	engine.Post("/signup", gee.E(func(ctx *gee.Context) error {
+		var form signup
+		return ctx.BindJSON(&form)
+	}))
+	safe := engine.Group("/safe")
+	safe.Use(gee.Recovery())
+	safe.Get("/panic", func(ctx *gee.Context) {
+		panic("boom")
+	})
+	engine.Get("/fatal", func(ctx *gee.Context) {
+		if ctx.Query("translate") != "" {
+			ctx.FatalT(http.StatusForbidden, "greeting", gee.H{"name": "Gee"})
+			return
+		}
+		ctx.Fatal(http.StatusForbidden, "greeting")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/signup?lang=zh-CN", strings.NewReader(`{"email": "a@b"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := engine.Test(req)
+
+	var problem gee.Problem
+	json.NewDecoder(res.Body).Decode(&problem)
+	if problem.Title != "请求错误" || problem.Detail != "参数校验失败" || len(problem.Errors) != 2 {
+		t.Fatalf("unexpected problem %+v", problem)
+	}
+	if problem.Errors[0].Message != "不能为空" || problem.Errors[1].Message != "长度不能少于 6" {
+		t.Fatalf("field errors should be translated, got %+v", problem.Errors)
+	}
+
+	// 没有翻译的语言使用内置的英文
+	req = httptest.NewRequest(http.MethodPost, "/signup?lang=fr", strings.NewReader(`{"email": "a@b"}`))
+	req.Header.Set("Content-Type", "application/json")
+	json.NewDecoder(engine.Test(req).Body).Decode(&problem)
+	if problem.Errors[0].Message != "est obligatoire" || problem.Errors[1].Message != "length must be at least 6" {
+		t.Fatalf("missing translation should fall back to english, got %+v", problem.Errors)
+	}
+
+	res = engine.Test(httptest.NewRequest(http.MethodGet, "/missing?lang=zh-CN", nil))
+	problem = gee.Problem{}
+	json.NewDecoder(res.Body).Decode(&problem)
+	if res.Code != http.StatusNotFound || problem.Detail != "找不到页面：/missing" {
+		t.Fatalf("404 message should be translated, got %+v", problem)
+	}
+
+	// 框架中间件的错误信息
+	res = engine.Test(httptest.NewRequest(http.MethodGet, "/safe/panic?lang=zh-CN", nil))
+	if res.Code != http.StatusInternalServerError || res.Body.String() != "{\"message\":\"服务器内部错误\"}\n" {
+		t.Fatalf("recovery message should be translated, got %q", res.Body.String())
+	}
+
+	// Fatal 原样输出，FatalT 按 key 翻译
+	res = engine.Test(httptest.NewRequest(http.MethodGet, "/fatal?lang=zh-CN", nil))
+	if res.Body.String() != "{\"message\":\"greeting\"}\n" {
+		t.Fatalf("fatal message should not be translated, got %q", res.Body.String())
+	}
+	res = engine.Test(httptest.NewRequest(http.MethodGet, "/fatal?lang=zh-CN&translate=1", nil))
+	if res.Body.String() != "{\"message\":\"你好，Gee\"}\n" {
+		t.Fatalf("fatalT message should be translated, got %q", res.Body.String())
+	}
+}
+
+func TestTemplateFunc(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`<html lang="{{ locale }}">{{ t "greeting" . }}</html>`), 0644)
+
+	engine := gee.New()
+	engine.AddFuncMap(FuncMap())
+	engine.LoadHTMLGlob(filepath.Join(dir, "*"))
+	engine.Use(New(Config{Bundle: newBundle(t)}))
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.HTML(http.StatusOK, "page.tmpl", gee.H{"name": "Gee"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "zh")
+	if body := engine.Test(req).Body.String(); body != `<html lang="zh-CN">你好，Gee</html>` {
+		t.Fatalf("unexpected page %q", body)
+	}
+}
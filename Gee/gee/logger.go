@@ -9,6 +9,11 @@ func Logger() HandlerFunc {
 	return func(ctx *Context) {
 		start := time.Now()
 		ctx.Next()
+		// 使用了RequestID中间件时，附带请求ID
+		if id := ctx.RequestID(); id != "" {
+			log.Printf("[M - Logger] [%d] %s in %v (%s)", ctx.StatusCode, ctx.Req.RequestURI, time.Since(start), id)
+			return
+		}
 		log.Printf("[M - Logger] [%d] %s in %v", ctx.StatusCode, ctx.Req.RequestURI, time.Since(start))
 	}
 }
\ No newline at end of file
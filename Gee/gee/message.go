@@ -0,0 +1,99 @@
+package gee
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* ---------------------------------- 本地化文本 ---------------------------------- */
+/**
+ * 框架输出的文本都通过 ctx.T 按 key 查找：
+ *  优先使用 i18n 中间件设置的 Translator
+ *  其次使用内置的英文文本
+ *  都不存在时 key 本身就是文本，因此 ctx.FatalT 也可以直接传入英文文本作为 key
+ * 参数为 gee.H 时替换文本中的 {name} 占位符，否则按 fmt.Sprintf 格式化
+ */
+
+// Translator 在 ctx 中的 key，由 i18n 中间件设置
+const TranslatorKey = "gee.translator"
+
+// 根据 key 查找当前语言的文本，不存在时返回 false
+type Translator func(key string) (string, bool)
+
+// 内置的英文文本
+var messages = map[string]string{
+	"gee.not_found":           "no route matches {path}",
+	"gee.method_not_allowed":  "method {method} is not allowed for {path}",
+	"validation.required":     "is required",
+	"validation.min":          "must be at least {param}",
+	"validation.max":          "must be at most {param}",
+	"validation.min_length":   "length must be at least {param}",
+	"validation.max_length":   "length must be at most {param}",
+	"validation.len":          "length must be {param}",
+	"validation.oneof":        "must be one of [{param}]",
+	"validation.email":        "must be a valid email address",
+	"validation.invalid_rule": "invalid rule {tag}",
+	"gee.unsupported_version": "unsupported API version: {version}",
+	"gee.bad_request":         "Bad Request",
+	"gee.unauthorized":        "Unauthorized",
+	"gee.forbidden":           "Forbidden",
+	"gee.too_many_requests":   "Too Many Requests",
+	"gee.internal_error":      "Internal Server Error",
+	"gee.bad_gateway":         "Bad Gateway",
+	"gee.service_unavailable": "Service Unavailable",
+	"auth.missing_api_key":    "Unauthorized - missing API key",
+	"auth.invalid_api_key":    "Unauthorized - invalid API key",
+	"auth.missing_token":      "Unauthorized - missing token",
+	"auth.invalid_token":      "Unauthorized - {error}",
+	"csrf.invalid_token":      "Forbidden - CSRF token invalid",
+	"secure.bad_host":         "Bad Host",
+}
+
+// 翻译 key 对应的文本
+//
+//	ctx.T("greeting", gee.H{"name": "Gee"})
+//	ctx.T("items", 3)
+func (ctx *Context) T(key string, args ...interface{}) string {
+	message, ok := "", false
+	if value, exists := ctx.Get(TranslatorKey); exists {
+		if translator, _ := value.(Translator); translator != nil {
+			message, ok = translator(key)
+		}
+	}
+	if !ok {
+		message = lookupMessage(key)
+	}
+
+	return FormatMessage(message, args...)
+}
+
+// 内置的英文文本，不存在时返回 key 本身
+func lookupMessage(key string) string {
+	if message, ok := messages[key]; ok {
+		return message
+	}
+	return key
+}
+
+// 格式化文本，参数为 gee.H 时替换 {name} 占位符，否则按 fmt.Sprintf 格式化
+func FormatMessage(message string, args ...interface{}) string {
+	if len(args) == 0 {
+		return message
+	}
+
+	var named map[string]interface{}
+	switch arg := args[0].(type) {
+	case H:
+		named = arg
+	case map[string]interface{}:
+		named = arg
+	default:
+		return fmt.Sprintf(message, args...)
+	}
+
+	replacements := make([]string, 0, len(named)*2)
+	for name, value := range named {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
@@ -0,0 +1,64 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+)
+
+/* ---------------------------------- 方法覆盖 ---------------------------------- */
+/**
+ * 浏览器表单只能发送 GET/POST，通过 X-HTTP-Method-Override 请求头或 _method 表单字段覆盖 POST 请求的方法
+ * 需要在路由匹配之前执行，因此通过 engine.Pre 注册：
+ *  engine.Pre(gee.MethodOverride())
+ */
+
+type MethodOverrideConfig struct {
+	// 请求头，默认 X-HTTP-Method-Override
+	Header string
+	// 表单字段，默认 _method
+	FormField string
+	// 允许覆盖为的方法，默认 PUT、PATCH、DELETE
+	Methods []string
+}
+
+// 默认配置的方法覆盖中间件
+func MethodOverride() HandlerFunc {
+	return MethodOverrideWithConfig(MethodOverrideConfig{})
+}
+
+func MethodOverrideWithConfig(config MethodOverrideConfig) HandlerFunc {
+	if config.Header == "" {
+		config.Header = "X-HTTP-Method-Override"
+	}
+	if config.FormField == "" {
+		config.FormField = "_method"
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	return func(ctx *Context) {
+		if ctx.Req.Method == http.MethodPost {
+			method := ctx.GetHeader(config.Header)
+			if method == "" && isFormRequest(ctx.Req) {
+				method = ctx.PostForm(config.FormField)
+			}
+
+			method = strings.ToUpper(strings.TrimSpace(method))
+			for _, allowed := range config.Methods {
+				if method == allowed {
+					ctx.Req.Method = method
+					break
+				}
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// 只有表单请求才解析请求体，避免读取 JSON 等请求体
+func isFormRequest(req *http.Request) bool {
+	contentType := req.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/x-www-form-urlencoded") || strings.HasPrefix(contentType, "multipart/form-data")
+}
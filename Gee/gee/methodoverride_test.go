@@ -0,0 +1,96 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	engine := New()
+	engine.Pre(MethodOverride())
+	engine.Use(func(ctx *Context) {
+		ctx.SetHeader("X-Method", ctx.Method)
+		ctx.Next()
+	})
+	engine.Post("/users/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "post")
+	})
+	engine.Put("/users/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "put %s", ctx.PostForm("name"))
+	})
+	engine.Delete("/users/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "delete %s", ctx.Param("id"))
+	})
+
+	form := url.Values{"_method": {"put"}, "name": {"gee"}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res := engine.Test(req)
+	if res.Body.String() != "put gee" || res.Header().Get("X-Method") != "PUT" {
+		t.Fatalf("_method should override the method before routing, got %q", res.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	if res := engine.Test(req); res.Body.String() != "delete 1" {
+		t.Fatalf("header should override the method, got %q", res.Body.String())
+	}
+
+	// 不允许覆盖为 GET，GET 请求也不能被覆盖
+	req = httptest.NewRequest(http.MethodPost, "/users/1?_method=PUT", nil)
+	req.Header.Set("X-HTTP-Method-Override", "GET")
+	if res := engine.Test(req); res.Body.String() != "post" {
+		t.Fatalf("only allowed methods should be used, got %q", res.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-HTTP-Method-Override", "DELETE")
+	if res := engine.Test(req); res.Code != http.StatusNotFound {
+		t.Fatalf("get requests should not be overridden, got %d", res.Code)
+	}
+
+	// JSON 请求体不会被读取
+	req = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"_method":"PUT"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if res := engine.Test(req); res.Body.String() != "post" {
+		t.Fatalf("json body should not be parsed, got %q", res.Body.String())
+	}
+}
+
+func TestPreMiddleware(t *testing.T) {
+	engine := New()
+	engine.Pre(func(ctx *Context) {
+		// 去掉末尾的 /
+		if len(ctx.Req.URL.Path) > 1 {
+			ctx.Req.URL.Path = strings.TrimSuffix(ctx.Req.URL.Path, "/")
+		}
+		ctx.Next()
+	}, func(ctx *Context) {
+		if ctx.GetHeader("X-Block") != "" {
+			ctx.Fatal(http.StatusForbidden, "Forbidden")
+			return
+		}
+		ctx.Next()
+	})
+	v1 := engine.Group("/v1")
+	v1.Use(func(ctx *Context) {
+		ctx.SetHeader("X-Group", "v1")
+		ctx.Next()
+	})
+	v1.Handle("patch", "/items", func(ctx *Context) {
+		ctx.String(http.StatusOK, "patched")
+	})
+
+	res := engine.Test(httptest.NewRequest(http.MethodPatch, "/v1/items/", nil))
+	if res.Body.String() != "patched" || res.Header().Get("X-Group") != "v1" {
+		t.Fatalf("rewritten path should be routed with group middlewares, got %d %q", res.Code, res.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/items", nil)
+	req.Header.Set("X-Block", "1")
+	if res := engine.Test(req); res.Code != http.StatusForbidden || res.Header().Get("X-Group") != "" {
+		t.Fatalf("pre middleware should be able to stop the request, got %d", res.Code)
+	}
+}
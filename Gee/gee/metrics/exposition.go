@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"bytes"
+	"gee-demo/gee"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* ----------------------------- Prometheus 文本格式 ----------------------------- */
+/**
+ * # HELP gee_http_requests_total Total number of HTTP requests.
+ * # TYPE gee_http_requests_total counter
+ * gee_http_requests_total{method="GET",route="/users/:id",status="2xx"} 3
+ */
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// 以 Prometheus 文本格式输出所有指标，挂载到 /metrics
+func (metrics *Metrics) Handler() gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		ctx.SetHeader("Content-Type", contentType)
+		ctx.Data(http.StatusOK, metrics.Gather())
+	}
+}
+
+// 生成当前所有指标的文本
+func (metrics *Metrics) Gather() []byte {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	var buf bytes.Buffer
+	name := metrics.namespace + "_http_"
+
+	writeHeader(&buf, name+"requests_total", "counter", "Total number of HTTP requests.")
+	requests := make([]requestLabels, 0, len(metrics.requests))
+	for labels := range metrics.requests {
+		requests = append(requests, labels)
+	}
+	for _, labels := range sortRequestLabels(requests) {
+		writeSample(&buf, name+"requests_total", labels.pairs(), float64(metrics.requests[labels]))
+	}
+
+	writeHeader(&buf, name+"requests_in_flight", "gauge", "Number of HTTP requests currently being served.")
+	inFlight := make([]routeLabels, 0, len(metrics.inFlight))
+	for labels := range metrics.inFlight {
+		inFlight = append(inFlight, labels)
+	}
+	sort.Slice(inFlight, func(i, j int) bool { return inFlight[i].less(inFlight[j]) })
+	for _, labels := range inFlight {
+		writeSample(&buf, name+"requests_in_flight", labels.pairs(), float64(metrics.inFlight[labels]))
+	}
+
+	writeHistograms(&buf, name+"request_duration_seconds", "HTTP request latency in seconds.", metrics.duration, metrics.durationBuckets)
+	writeHistograms(&buf, name+"response_size_bytes", "HTTP response size in bytes.", metrics.size, metrics.sizeBuckets)
+
+	return buf.Bytes()
+}
+
+func writeHistograms(buf *bytes.Buffer, name string, help string, histograms map[requestLabels]*histogram, buckets []float64) {
+	writeHeader(buf, name, "histogram", help)
+
+	keys := make([]requestLabels, 0, len(histograms))
+	for labels := range histograms {
+		keys = append(keys, labels)
+	}
+	for _, labels := range sortRequestLabels(keys) {
+		h := histograms[labels]
+		pairs := labels.pairs()
+
+		// 分桶计数需要累计
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(buckets) {
+				le = buckets[i]
+			}
+			writeSample(buf, name+"_bucket", append(pairs, "le", formatFloat(le)), float64(cumulative))
+		}
+		writeSample(buf, name+"_sum", pairs, h.sum)
+		writeSample(buf, name+"_count", pairs, float64(h.count))
+	}
+}
+
+func writeHeader(buf *bytes.Buffer, name string, typ string, help string) {
+	buf.WriteString("# HELP " + name + " " + help + "\n")
+	buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// pairs 为 [name1, value1, name2, value2...]
+func writeSample(buf *bytes.Buffer, name string, pairs []string, value float64) {
+	buf.WriteString(name)
+	buf.WriteByte('{')
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(pairs[i] + "=\"" + escapeLabel(pairs[i+1]) + "\"")
+	}
+	buf.WriteString("} ")
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func (labels routeLabels) pairs() []string {
+	return []string{"method", labels.method, "route", labels.route}
+}
+
+func (labels requestLabels) pairs() []string {
+	return append(labels.routeLabels.pairs(), "status", labels.status)
+}
+
+func (labels routeLabels) less(other routeLabels) bool {
+	if labels.route != other.route {
+		return labels.route < other.route
+	}
+	return labels.method < other.method
+}
+
+// 按标签排序，保证输出稳定
+func sortRequestLabels(keys []requestLabels) []requestLabels {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].routeLabels != keys[j].routeLabels {
+			return keys[i].routeLabels.less(keys[j].routeLabels)
+		}
+		return keys[i].status < keys[j].status
+	})
+	return keys
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 指标统计 ---------------------------------- */
+/**
+ * 按照 RED 方法统计每个路由的指标：
+ *  {namespace}_http_requests_total             请求数(counter)
+ *  {namespace}_http_requests_in_flight         正在处理的请求数(gauge)
+ *  {namespace}_http_request_duration_seconds   请求耗时(histogram)
+ *  {namespace}_http_response_size_bytes        响应大小(histogram)
+ * 标签使用路由规则(如 /users/:id)而不是实际路径，状态码按 2xx/4xx 等分类，非标准的方法统一为 OTHER，避免标签基数膨胀
+ */
+
+var (
+	// 默认的耗时分桶(秒)
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// 默认的响应大小分桶(字节)
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+const (
+	// 未匹配到路由时的 route 标签
+	unmatchedRoute = "<unmatched>"
+	// 非标准方法的 method 标签
+	otherMethod = "OTHER"
+)
+
+type Config struct {
+	// 指标名前缀，默认 gee
+	Namespace       string
+	DurationBuckets []float64
+	SizeBuckets     []float64
+}
+
+type Metrics struct {
+	namespace string
+	mutex     sync.Mutex
+
+	requests map[requestLabels]uint64
+	inFlight map[routeLabels]int64
+	duration map[requestLabels]*histogram
+	size     map[requestLabels]*histogram
+
+	durationBuckets []float64
+	sizeBuckets     []float64
+}
+
+type routeLabels struct {
+	method string
+	route  string
+}
+
+type requestLabels struct {
+	routeLabels
+	status string
+}
+
+type histogram struct {
+	// 每个分桶的计数(非累计)，最后一个为 +Inf
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// 实例化一个指标统计
+func New(config Config) *Metrics {
+	if config.Namespace == "" {
+		config.Namespace = "gee"
+	}
+	if config.DurationBuckets == nil {
+		config.DurationBuckets = DefaultDurationBuckets
+	}
+	if config.SizeBuckets == nil {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+
+	return &Metrics{
+		namespace:       config.Namespace,
+		requests:        make(map[requestLabels]uint64),
+		inFlight:        make(map[routeLabels]int64),
+		duration:        make(map[requestLabels]*histogram),
+		size:            make(map[requestLabels]*histogram),
+		durationBuckets: config.DurationBuckets,
+		sizeBuckets:     config.SizeBuckets,
+	}
+}
+
+// 统计中间件
+func (metrics *Metrics) Middleware() gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		start := time.Now()
+		route := routeLabels{method: methodLabel(ctx.Method), route: ctx.Pattern}
+		if route.route == "" {
+			route.route = unmatchedRoute
+		}
+
+		metrics.addInFlight(route, 1)
+		defer metrics.addInFlight(route, -1)
+
+		writer := &countingWriter{ResponseWriter: ctx.Res}
+		ctx.Res = writer
+		defer func() {
+			ctx.Res = writer.ResponseWriter
+		}()
+
+		ctx.Next()
+
+		status := writer.status
+		if status == 0 {
+			status = ctx.StatusCode
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.observe(requestLabels{routeLabels: route, status: statusClass(status)}, time.Since(start), writer.size)
+	}
+}
+
+// 标准方法使用原值，其余的统一为 OTHER
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+func (metrics *Metrics) addInFlight(labels routeLabels, delta int64) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.inFlight[labels] += delta
+}
+
+func (metrics *Metrics) observe(labels requestLabels, duration time.Duration, size int) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.requests[labels]++
+
+	h, ok := metrics.duration[labels]
+	if !ok {
+		h = newHistogram(metrics.durationBuckets)
+		metrics.duration[labels] = h
+	}
+	h.observe(metrics.durationBuckets, duration.Seconds())
+
+	h, ok = metrics.size[labels]
+	if !ok {
+		h = newHistogram(metrics.sizeBuckets)
+		metrics.size[labels] = h
+	}
+	h.observe(metrics.sizeBuckets, float64(size))
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{counts: make([]uint64, len(buckets)+1)}
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	i := 0
+	for i < len(buckets) && value > buckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.sum += value
+	h.count++
+}
+
+// 200 -> 2xx
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+/* ------------------------------ 统计响应的 Writer ------------------------------ */
+
+type countingWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *countingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *countingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
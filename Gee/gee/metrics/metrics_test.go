@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := New(Config{DurationBuckets: []float64{0.5, 1}, SizeBuckets: []float64{1, 10}})
+	engine := gee.New()
+	engine.Use(metrics.Middleware())
+	engine.Get("/users/:id", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "user %s", ctx.Param("id"))
+	})
+	engine.Get("/metrics", metrics.Handler())
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("X-RANDOM-1", "/users/1", nil))
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", res.Header().Get("Content-Type"))
+	}
+
+	body := res.Body.String()
+	expects := []string{
+		"# TYPE gee_http_requests_total counter\n",
+		`gee_http_requests_total{method="GET",route="/users/:id",status="2xx"} 2` + "\n",
+		`gee_http_requests_total{method="GET",route="<unmatched>",status="4xx"} 1` + "\n",
+		`gee_http_requests_total{method="OTHER",route="<unmatched>",status="4xx"} 1` + "\n",
+		`gee_http_requests_in_flight{method="GET",route="/metrics"} 1` + "\n",
+		`gee_http_requests_in_flight{method="GET",route="/users/:id"} 0` + "\n",
+		`gee_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="+Inf"} 2` + "\n",
+		`gee_http_request_duration_seconds_count{method="GET",route="/users/:id",status="2xx"} 2` + "\n",
+		`gee_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="1"} 0` + "\n",
+		`gee_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="10"} 2` + "\n",
+		`gee_http_response_size_bytes_sum{method="GET",route="/users/:id",status="2xx"} 12` + "\n",
+	}
+	for _, expect := range expects {
+		if !strings.Contains(body, expect) {
+			t.Fatalf("metrics should contain %q, got:\n%s", expect, body)
+		}
+	}
+	if strings.Contains(body, "/users/1") || strings.Contains(body, "X-RANDOM-1") {
+		t.Fatalf("raw path and method should not be used as label")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escape label got %s", got)
+	}
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/* ---------------------------------- OpenAPI ---------------------------------- */
+/**
+ * 根据已注册的路由生成 OpenAPI 3 文档：
+ *  :name、*name 转换为 {name} 路径参数
+ *  RouteDoc.Request 在 GET/HEAD/DELETE 中按 form 标签生成 Query 参数，其他方法作为 JSON 请求体
+ *  RouteDoc.Response 作为成功响应，错误响应统一为 application/problem+json
+ */
+
+type Config struct {
+	Title       string
+	Description string
+	// 文档版本，默认 1.0.0
+	Version string
+	// 文档的访问路径，默认 /openapi.json
+	Path string
+}
+
+// 注册文档路由，文档在第一次访问时生成，因此需要在所有路由注册完成后再访问
+func Register(engine *gee.Engine, config Config) *gee.Route {
+	if config.Path == "" {
+		config.Path = "/openapi.json"
+	}
+
+	var once sync.Once
+	var document *Document
+
+	return engine.Get(config.Path, func(ctx *gee.Context) {
+		once.Do(func() {
+			document = Generate(engine, config)
+		})
+		ctx.JSON(http.StatusOK, document)
+	})
+}
+
+// 生成 OpenAPI 文档，文档自身的路由不会被包含
+func Generate(engine *gee.Engine, config Config) *Document {
+	if config.Version == "" {
+		config.Version = "1.0.0"
+	}
+
+	generator := newSchemaGenerator()
+	problem := generator.schemaOf(reflect.TypeOf(gee.Problem{}))
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: config.Title, Description: config.Description, Version: config.Version},
+		Paths:   make(map[string]map[string]*Operation),
+	}
+
+	for _, route := range engine.Routes() {
+		if config.Path != "" && route.Pattern == config.Path {
+			continue
+		}
+
+		path, parameters := convertPath(route.Pattern)
+		operation := &Operation{
+			Summary:     route.Meta.Summary,
+			Description: route.Meta.Description,
+			Tags:        route.Meta.Tags,
+			Parameters:  parameters,
+			Deprecated:  route.Meta.Deprecated,
+			Responses: map[string]*Response{
+				"default": {
+					Description: "Error",
+					Content:     map[string]*MediaType{"application/problem+json": {Schema: problem}},
+				},
+			},
+		}
+
+		if route.Meta.Request != nil {
+			requestType := reflect.TypeOf(route.Meta.Request)
+			switch route.Method {
+			case http.MethodGet, http.MethodHead, http.MethodDelete:
+				operation.Parameters = append(operation.Parameters, queryParameters(generator, requestType)...)
+			default:
+				operation.RequestBody = &RequestBody{
+					Required: true,
+					Content:  map[string]*MediaType{"application/json": {Schema: generator.schemaOf(requestType)}},
+				}
+			}
+		}
+
+		status := route.Meta.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := &Response{Description: http.StatusText(status)}
+		if route.Meta.Response != nil {
+			response.Content = map[string]*MediaType{
+				"application/json": {Schema: generator.schemaOf(reflect.TypeOf(route.Meta.Response))},
+			}
+		}
+		operation.Responses[strconv.Itoa(status)] = response
+
+		if document.Paths[path] == nil {
+			document.Paths[path] = make(map[string]*Operation)
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	document.Components.Schemas = generator.schemas
+	return document
+}
+
+// 将 /users/:id 转换为 /users/{id}，并生成路径参数
+func convertPath(pattern string) (string, []*Parameter) {
+	parts := strings.Split(pattern, "/")
+	parameters := make([]*Parameter, 0)
+
+	for i, part := range parts {
+		if part == "" || (part[0] != ':' && part[0] != '*') {
+			continue
+		}
+		name := part[1:]
+		parts[i] = "{" + name + "}"
+		parameters = append(parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	return strings.Join(parts, "/"), parameters
+}
+
+// 按 form 标签生成 Query 参数
+func queryParameters(generator *schemaGenerator, t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	parameters := make([]*Parameter, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			parameters = append(parameters, queryParameters(generator, field.Type)...)
+			continue
+		}
+
+		name, ok := gee.FieldName(field, "form")
+		if !ok {
+			continue
+		}
+		schema := generator.schemaOf(field.Type)
+		required := applyRules(schema, field)
+		parameters = append(parameters, &Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+
+	return parameters
+}
+
+/* ---------------------------------- 插件 ---------------------------------- */
+
+type openAPIPlugin struct {
+	config Config
+}
+
+// 以插件的形式注册文档路由
+//
+//	engine.Install(openapi.Plugin(openapi.Config{Title: "Demo"}))
+func Plugin(config Config) gee.Plugin {
+	return &openAPIPlugin{config: config}
+}
+
+func (plugin *openAPIPlugin) Name() string {
+	return "openapi"
+}
+
+func (plugin *openAPIPlugin) Install(engine *gee.Engine) {
+	Register(engine, plugin.config)
+}
@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"encoding/json"
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type Address struct {
+	City string `json:"city" binding:"required"`
+}
+
+type CreateUser struct {
+	Name      string    `json:"name" binding:"required,min=2,max=20"`
+	Email     string    `json:"email" binding:"email"`
+	Role      string    `json:"role" binding:"oneof=admin user"`
+	Age       int       `json:"age" binding:"min=0,max=150"`
+	Tags      []string  `json:"tags" binding:"max=5"`
+	Address   *Address  `json:"address" binding:"required"`
+	CreatedAt time.Time `json:"createdAt"`
+	Internal  string    `json:"-"`
+}
+
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ListUsers struct {
+	Page int    `form:"page" binding:"min=1"`
+	Sort string `form:"sort" binding:"required,oneof=asc desc"`
+}
+
+func newEngine() *gee.Engine {
+	engine := gee.New()
+	api := engine.Group("/api")
+	api.Get("/users", func(ctx *gee.Context) {}).Doc(gee.RouteDoc{Summary: "List users", Tags: []string{"users"}, Request: ListUsers{}, Response: []User{}})
+	api.Get("/users/:id", func(ctx *gee.Context) {}).Doc(gee.RouteDoc{Summary: "Get user", Response: User{}})
+	api.Post("/users", func(ctx *gee.Context) {}).Doc(gee.RouteDoc{Request: CreateUser{}, Response: &User{}, Status: http.StatusCreated})
+	api.Get("/files/*filepath", func(ctx *gee.Context) {})
+	Register(engine, Config{Title: "Demo", Path: "/docs/openapi.json"})
+	return engine
+}
+
+func TestServeDocument(t *testing.T) {
+	engine := newEngine()
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+
+	var document Document
+	if err := json.NewDecoder(res.Body).Decode(&document); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %v", res.Code, err)
+	}
+	if document.OpenAPI != "3.0.3" || document.Info.Title != "Demo" || document.Info.Version != "1.0.0" {
+		t.Fatalf("unexpected document info %+v", document.Info)
+	}
+	if _, ok := document.Paths["/docs/openapi.json"]; ok {
+		t.Fatalf("document route should be excluded")
+	}
+	if len(document.Paths) != 3 {
+		t.Fatalf("expect 3 paths, but got %d", len(document.Paths))
+	}
+}
+
+func TestPathParameters(t *testing.T) {
+	document := Generate(newEngine(), Config{})
+
+	operation := document.Paths["/api/users/{id}"]["get"]
+	if operation == nil || operation.Summary != "Get user" {
+		t.Fatalf(":id should be converted to {id}, got %v", document.Paths)
+	}
+	if len(operation.Parameters) != 1 || operation.Parameters[0].Name != "id" || operation.Parameters[0].In != "path" || !operation.Parameters[0].Required {
+		t.Fatalf("unexpected path parameters %+v", operation.Parameters)
+	}
+	if document.Paths["/api/files/{filepath}"]["get"] == nil {
+		t.Fatalf("*filepath should be converted to {filepath}")
+	}
+	if operation.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/User" {
+		t.Fatalf("response should reference User schema")
+	}
+	if operation.Responses["default"].Content["application/problem+json"].Schema.Ref != "#/components/schemas/Problem" {
+		t.Fatalf("error response should reference Problem schema")
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	document := Generate(newEngine(), Config{})
+
+	operation := document.Paths["/api/users"]["get"]
+	if len(operation.Parameters) != 2 {
+		t.Fatalf("expect 2 query parameters, but got %+v", operation.Parameters)
+	}
+	page, sort := operation.Parameters[0], operation.Parameters[1]
+	if page.Name != "page" || page.In != "query" || page.Required || *page.Schema.Minimum != 1 {
+		t.Fatalf("unexpected page parameter %+v", page)
+	}
+	if sort.Name != "sort" || !sort.Required || !reflect.DeepEqual(sort.Schema.Enum, []interface{}{"asc", "desc"}) {
+		t.Fatalf("unexpected sort parameter %+v", sort)
+	}
+	if operation.Responses["200"].Content["application/json"].Schema.Type != "array" {
+		t.Fatalf("response should be an array")
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	document := Generate(newEngine(), Config{})
+
+	operation := document.Paths["/api/users"]["post"]
+	if operation.RequestBody == nil || operation.Responses["201"] == nil {
+		t.Fatalf("post should have request body and 201 response")
+	}
+
+	schema := document.Components.Schemas["CreateUser"]
+	if schema == nil {
+		t.Fatalf("CreateUser schema should be registered")
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"name", "address"}) {
+		t.Fatalf("unexpected required fields %v", schema.Required)
+	}
+	if _, ok := schema.Properties["Internal"]; ok {
+		t.Fatalf(`json:"-" should be ignored`)
+	}
+
+	name := schema.Properties["name"]
+	if *name.MinLength != 2 || *name.MaxLength != 20 {
+		t.Fatalf("unexpected name schema %+v", name)
+	}
+	if schema.Properties["email"].Format != "email" || schema.Properties["createdAt"].Format != "date-time" {
+		t.Fatalf("unexpected formats")
+	}
+	if *schema.Properties["tags"].MaxItems != 5 || *schema.Properties["age"].Maximum != 150 {
+		t.Fatalf("unexpected limits")
+	}
+	if schema.Properties["address"].Ref != "#/components/schemas/Address" || document.Components.Schemas["Address"].Required[0] != "city" {
+		t.Fatalf("nested struct should be referenced")
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	engine := gee.New()
+	engine.Install(Plugin(Config{Title: "Plugin"}))
+	engine.Get("/ping", func(ctx *gee.Context) {}).Doc(gee.RouteDoc{Summary: "Ping"})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var document Document
+	json.NewDecoder(res.Body).Decode(&document)
+	if document.Info.Title != "Plugin" || document.Paths["/ping"]["get"].Summary != "Ping" {
+		t.Fatalf("plugin should serve the document, got %+v", document)
+	}
+}
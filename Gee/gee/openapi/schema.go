@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"gee-demo/gee"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ---------------------------------- 结构体转 Schema ---------------------------------- */
+/**
+ * 通过反射将 Go 类型转换为 Schema，具名结构体放入 components.schemas 并以 $ref 引用
+ * 字段名使用 json 标签，binding 标签转换为约束：
+ *  required             -> required
+ *  min/max/len          -> minimum/maximum、minLength/maxLength、minItems/maxItems
+ *  oneof                -> enum
+ *  email                -> format: email
+ */
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	// 类型对应的组件名，处理同名类型与递归引用
+	names map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// 生成类型的 Schema
+func (generator *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return &Schema{Ref: "#/components/schemas/" + generator.component(t)}
+	case t.Kind() == reflect.Struct:
+		return generator.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: generator.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: generator.schemaOf(t.Elem())}
+	}
+
+	// interface{} 等无法确定的类型
+	return &Schema{}
+}
+
+// 注册具名结构体，返回组件名
+func (generator *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := generator.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	// 不同包的同名类型，加上包名区分
+	if _, exists := generator.schemas[name]; exists {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	generator.names[t] = name
+	// 先占位，避免递归类型无限展开
+	generator.schemas[name] = &Schema{}
+	*generator.schemas[name] = *generator.structSchema(t)
+
+	return name
+}
+
+func (generator *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	generator.addFields(schema, t)
+	return schema
+}
+
+func (generator *schemaGenerator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		// 匿名嵌入的结构体，展开字段
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			generator.addFields(schema, field.Type)
+			continue
+		}
+
+		name, ok := gee.FieldName(field, "json")
+		if !ok {
+			continue
+		}
+
+		property := generator.schemaOf(field.Type)
+		if applyRules(property, field) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// 将 binding 标签转换为约束，返回是否必填
+func applyRules(schema *Schema, field reflect.StructField) bool {
+	required := false
+	// $ref 不能与其他属性并存
+	if schema.Ref != "" {
+		for _, rule := range gee.ParseRules(field.Tag.Get("binding")) {
+			if rule.Tag == "required" {
+				required = true
+			}
+		}
+		return required
+	}
+
+	for _, rule := range gee.ParseRules(field.Tag.Get("binding")) {
+		switch rule.Tag {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			setLimit(schema, rule)
+		case "oneof":
+			for _, option := range strings.Fields(rule.Param) {
+				schema.Enum = append(schema.Enum, enumValue(schema.Type, option))
+			}
+		case "email":
+			schema.Format = "email"
+		}
+	}
+
+	return required
+}
+
+func setLimit(schema *Schema, rule gee.Rule) {
+	limit, err := strconv.ParseFloat(rule.Param, 64)
+	if err != nil {
+		return
+	}
+	size := int(limit)
+
+	switch schema.Type {
+	case "integer", "number":
+		if rule.Tag == "min" || rule.Tag == "len" {
+			schema.Minimum = &limit
+		}
+		if rule.Tag == "max" || rule.Tag == "len" {
+			schema.Maximum = &limit
+		}
+	case "string":
+		if rule.Tag == "min" || rule.Tag == "len" {
+			schema.MinLength = &size
+		}
+		if rule.Tag == "max" || rule.Tag == "len" {
+			schema.MaxLength = &size
+		}
+	case "array":
+		if rule.Tag == "min" || rule.Tag == "len" {
+			schema.MinItems = &size
+		}
+		if rule.Tag == "max" || rule.Tag == "len" {
+			schema.MaxItems = &size
+		}
+	}
+}
+
+// 根据类型转换 enum 的值
+func enumValue(schemaType string, option string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(option, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(option, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(option); err == nil {
+			return b
+		}
+	}
+	return option
+}
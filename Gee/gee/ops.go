@@ -0,0 +1,200 @@
+package gee
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 运维接口 ---------------------------------- */
+/**
+ * engine.Ops(prefix, checks...) 注册运维接口：
+ *  GET prefix/livez   存活检查，只运行 Liveness 为 true 的检查，/healthz 同 /livez
+ *  GET prefix/readyz  就绪检查，运行所有检查
+ * 检查并发执行，各自有超时时间，全部通过返回 200，否则返回 503，响应中包含每个检查的结果
+ * ops.Debug(auth) 在 prefix/debug 下挂载 pprof 与 expvar，必须指定认证中间件
+ */
+
+// 检查函数，返回 error 表示检查失败
+type Checker func(ctx context.Context) error
+
+type Check struct {
+	Name    string
+	Checker Checker
+	// 超时时间，默认 2s
+	Timeout time.Duration
+	// 为 true 时同时用于存活检查，通常只有死锁等无法自愈的问题才应该让存活检查失败
+	Liveness bool
+}
+
+// 单个检查的结果
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// 检查的汇总结果
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type Ops struct {
+	group  *RouterGroup
+	checks []Check
+}
+
+// 注册存活与就绪检查接口
+func (engine *Engine) Ops(prefix string, checks ...Check) *Ops {
+	for i := range checks {
+		if checks[i].Timeout <= 0 {
+			checks[i].Timeout = 2 * time.Second
+		}
+	}
+
+	ops := &Ops{group: engine.Group(prefix), checks: checks}
+
+	liveness := func(ctx *Context) {
+		ops.respond(ctx, ops.run(ctx.Req.Context(), true))
+	}
+	ops.group.Get("/livez", liveness)
+	ops.group.Get("/healthz", liveness)
+	ops.group.Get("/readyz", func(ctx *Context) {
+		ops.respond(ctx, ops.run(ctx.Req.Context(), false))
+	})
+
+	return ops
+}
+
+// 并发执行检查
+func (ops *Ops) run(ctx context.Context, livenessOnly bool) HealthReport {
+	report := HealthReport{Status: StatusOK, Checks: make(map[string]CheckResult)}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range ops.checks {
+		if livenessOnly && !check.Liveness {
+			continue
+		}
+
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- check.Checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// 检查函数没有响应 ctx 时，不再等待
+		err = fmt.Errorf("timeout after %s", check.Timeout)
+	}
+
+	result := CheckResult{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (ops *Ops) respond(ctx *Context, report HealthReport) {
+	ctx.SetHeader("Cache-Control", "no-store")
+	if report.Status != StatusOK {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+/* ---------------------------------- pprof & expvar ---------------------------------- */
+
+// 在 prefix/debug 下挂载 pprof 与 expvar：
+//
+//	prefix/debug/pprof/       pprof 首页，以及 heap、goroutine、profile、trace 等
+//	prefix/debug/vars         expvar
+func (ops *Ops) Debug(auth HandlerFunc, middlewares ...HandlerFunc) *Ops {
+	if auth == nil {
+		panic("gee: ops debug endpoints require an auth middleware")
+	}
+
+	debug := ops.group.Group("/debug")
+	debug.Use(auth)
+	debug.Use(middlewares...)
+
+	indexPath := debug.prefix + "/pprof/"
+	index := func(ctx *Context) {
+		// 首页中的链接是相对路径，需要以 / 结尾
+		if !strings.HasSuffix(ctx.Req.URL.Path, "/") {
+			http.Redirect(ctx.Res, ctx.Req, indexPath, http.StatusMovedPermanently)
+			return
+		}
+		pprof.Index(ctx.Res, ctx.Req)
+	}
+	debug.Get("/pprof", index)
+	debug.Get("/pprof/*name", func(ctx *Context) {
+		switch name := ctx.Param("name"); name {
+		case "":
+			index(ctx)
+		case "cmdline":
+			pprof.Cmdline(ctx.Res, ctx.Req)
+		case "profile":
+			pprof.Profile(ctx.Res, ctx.Req)
+		case "symbol":
+			pprof.Symbol(ctx.Res, ctx.Req)
+		case "trace":
+			pprof.Trace(ctx.Res, ctx.Req)
+		default:
+			pprof.Handler(name).ServeHTTP(ctx.Res, ctx.Req)
+		}
+	})
+	debug.Post("/pprof/symbol", func(ctx *Context) {
+		pprof.Symbol(ctx.Res, ctx.Req)
+	})
+
+	vars := expvar.Handler()
+	debug.Get("/vars", func(ctx *Context) {
+		vars.ServeHTTP(ctx.Res, ctx.Req)
+	})
+
+	return ops
+}
@@ -0,0 +1,110 @@
+package gee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpsChecks(t *testing.T) {
+	dbReady := false
+	engine := New()
+	engine.Ops("/ops",
+		Check{Name: "deadlock", Liveness: true, Checker: func(ctx context.Context) error { return nil }},
+		Check{Name: "db", Checker: func(ctx context.Context) error {
+			if !dbReady {
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		Check{Name: "slow", Timeout: 10 * time.Millisecond, Checker: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	var report HealthReport
+	res := engine.Test(httptest.NewRequest(http.MethodGet, "/ops/livez", nil))
+	json.NewDecoder(res.Body).Decode(&report)
+	if res.Code != http.StatusOK || report.Status != StatusOK || len(report.Checks) != 1 || report.Checks["deadlock"].Status != StatusOK {
+		t.Fatalf("liveness should only run liveness checks, got %d %+v", res.Code, report)
+	}
+
+	res = engine.Test(httptest.NewRequest(http.MethodGet, "/ops/readyz", nil))
+	report = HealthReport{}
+	json.NewDecoder(res.Body).Decode(&report)
+	if res.Code != http.StatusServiceUnavailable || report.Status != StatusFail || len(report.Checks) != 3 {
+		t.Fatalf("readiness should fail, got %d %+v", res.Code, report)
+	}
+	if report.Checks["db"].Error != "connection refused" || report.Checks["slow"].Status != StatusFail {
+		t.Fatalf("unexpected check results %+v", report.Checks)
+	}
+	if res.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("health responses should not be cached")
+	}
+}
+
+func TestOpsCheckPanicAndTimeout(t *testing.T) {
+	engine := New()
+	engine.Ops("",
+		Check{Name: "panic", Checker: func(ctx context.Context) error { panic("boom") }},
+		// 不响应 ctx 的检查也会在超时后返回
+		Check{Name: "stuck", Timeout: 10 * time.Millisecond, Checker: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}},
+	)
+
+	start := time.Now()
+	res := engine.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("stuck check should not block the response")
+	}
+	if res.Code != http.StatusServiceUnavailable || !strings.Contains(res.Body.String(), "panic: boom") || !strings.Contains(res.Body.String(), "timeout after 10ms") {
+		t.Fatalf("unexpected response %d %s", res.Code, res.Body.String())
+	}
+}
+
+func TestOpsDebug(t *testing.T) {
+	engine := New()
+	engine.Ops("/ops").Debug(func(ctx *Context) {
+		if ctx.GetHeader("X-Token") != "secret" {
+			ctx.Fatal(http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		ctx.Next()
+	})
+
+	// 多余的 / 不能绕过认证
+	for _, path := range []string{"/ops/debug/vars", "//ops/debug/vars", "/ops//debug/vars", "/ops/debug//pprof/", "/ops/debug/pprof//goroutine"} {
+		res := engine.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if res.Code != http.StatusUnauthorized {
+			t.Fatalf("%s should require auth, got %d", path, res.Code)
+		}
+	}
+
+	cases := []struct {
+		path     string
+		status   int
+		fragment string
+	}{
+		{"/ops/debug/vars", http.StatusOK, `"memstats"`},
+		{"/ops/debug/pprof/", http.StatusOK, "goroutine"},
+		{"/ops/debug/pprof", http.StatusMovedPermanently, ""},
+		{"/ops/debug/pprof/goroutine?debug=1", http.StatusOK, "goroutine profile"},
+		{"/ops/debug/pprof/cmdline", http.StatusOK, ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.Header.Set("X-Token", "secret")
+		res := engine.Test(req)
+		if res.Code != c.status || !strings.Contains(res.Body.String(), c.fragment) {
+			t.Fatalf("%s: unexpected response %d", c.path, res.Code)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+/* ---------------------------------- 插件与生命周期 ---------------------------------- */
+/**
+ * Plugin 通过 Install 注册中间件、路由或生命周期钩子：
+ *  OnRouteRegistered  注册路由时调用，注册钩子之前已有的路由会立即补调
+ *  OnStart            Run 开始监听之前调用，返回 error 时不再启动
+ *  OnShutdown         Shutdown 等待请求处理完成之后，按注册的逆序调用
+ *  OnError            ctx.Error 与 Recovery 捕获 panic 时调用，在 ErrorHandler 之前
+ */
+
+type Plugin interface {
+	// 插件名称，同名的插件只会安装一次
+	Name() string
+	Install(engine *Engine)
+}
+
+// 生命周期钩子
+type hooks struct {
+	routeRegistered []func(route *Route)
+	start           []func() error
+	shutdown        []func(ctx context.Context) error
+	errors          []func(ctx *Context, err error)
+}
+
+// 安装插件，同名的插件已安装时跳过
+func (engine *Engine) Install(plugins ...Plugin) {
+	for _, plugin := range plugins {
+		if engine.Plugin(plugin.Name()) != nil {
+			continue
+		}
+		engine.plugins = append(engine.plugins, plugin)
+		plugin.Install(engine)
+	}
+}
+
+// 根据名称获取已安装的插件，未安装时返回 nil
+func (engine *Engine) Plugin(name string) Plugin {
+	for _, plugin := range engine.plugins {
+		if plugin.Name() == name {
+			return plugin
+		}
+	}
+	return nil
+}
+
+// 注册路由时调用，此时 Route.Doc 还未设置，需要读取文档信息的插件应在启动时读取
+func (engine *Engine) OnRouteRegistered(hook func(route *Route)) {
+	engine.hooks.routeRegistered = append(engine.hooks.routeRegistered, hook)
+	for _, route := range engine.router.routes {
+		hook(route)
+	}
+}
+
+// 开始监听之前调用
+func (engine *Engine) OnStart(hook func() error) {
+	engine.hooks.start = append(engine.hooks.start, hook)
+}
+
+// 服务关闭之后调用
+func (engine *Engine) OnShutdown(hook func(ctx context.Context) error) {
+	engine.hooks.shutdown = append(engine.hooks.shutdown, hook)
+}
+
+// 处理请求出错时调用
+func (engine *Engine) OnError(hook func(ctx *Context, err error)) {
+	engine.hooks.errors = append(engine.hooks.errors, hook)
+}
+
+func (engine *Engine) routeRegistered(route *Route) {
+	for _, hook := range engine.hooks.routeRegistered {
+		hook(route)
+	}
+}
+
+func (ctx *Context) notifyError(err error) {
+	if ctx.engine == nil {
+		return
+	}
+	for _, hook := range ctx.engine.hooks.errors {
+		hook(ctx, err)
+	}
+}
+
+/* ---------------------------------- 启动与关闭 ---------------------------------- */
+
+// 调用 OnStart 钩子并开始监听，Shutdown 之后返回 nil
+func (engine *Engine) serve(server *http.Server, listen func() error) error {
+	engine.serverMutex.Lock()
+	if engine.server != nil {
+		engine.serverMutex.Unlock()
+		return errors.New("gee: server is already running")
+	}
+	engine.server = server
+	engine.serverMutex.Unlock()
+
+	for _, hook := range engine.hooks.start {
+		if err := hook(); err != nil {
+			engine.clearServer(server)
+			return fmt.Errorf("gee: start hook: %w", err)
+		}
+	}
+
+	if err := listen(); err != nil && err != http.ErrServerClosed {
+		// 监听失败(如端口被占用)，允许再次启动
+		engine.clearServer(server)
+		return err
+	}
+	return nil
+}
+
+// 清除启动失败的服务器，已被 Shutdown 或替换时不处理
+func (engine *Engine) clearServer(server *http.Server) {
+	engine.serverMutex.Lock()
+	defer engine.serverMutex.Unlock()
+
+	if engine.server == server {
+		engine.server = nil
+	}
+}
+
+// 优雅关闭：停止接收新的请求，等待处理中的请求完成后，按注册的逆序调用 OnShutdown 钩子
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.serverMutex.Lock()
+	server := engine.server
+	engine.server = nil
+	engine.serverMutex.Unlock()
+
+	var errs []error
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(engine.hooks.shutdown) - 1; i >= 0; i-- {
+		if err := engine.hooks.shutdown[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
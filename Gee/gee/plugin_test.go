@@ -0,0 +1,131 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type routesPlugin struct {
+	installs int
+	routes   []string
+}
+
+func (plugin *routesPlugin) Name() string {
+	return "routes"
+}
+
+func (plugin *routesPlugin) Install(engine *Engine) {
+	plugin.installs++
+	engine.OnRouteRegistered(func(route *Route) {
+		plugin.routes = append(plugin.routes, route.Method+" "+route.Pattern)
+	})
+}
+
+func TestPlugin(t *testing.T) {
+	engine := New()
+	engine.Get("/before", func(ctx *Context) {})
+
+	plugin := &routesPlugin{}
+	engine.Install(plugin, &routesPlugin{})
+	engine.Install(plugin)
+	engine.Group("/api").Post("/users", func(ctx *Context) {})
+
+	if plugin.installs != 1 || engine.Plugin("routes") != plugin || engine.Plugin("missing") != nil {
+		t.Fatalf("plugin should be installed once")
+	}
+	if len(plugin.routes) != 2 || plugin.routes[0] != "GET /before" || plugin.routes[1] != "POST /api/users" {
+		t.Fatalf("unexpected registered routes %v", plugin.routes)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	var errs []string
+	engine := New()
+	engine.Use(Recovery())
+	engine.OnError(func(ctx *Context, err error) {
+		errs = append(errs, ctx.Path+" "+err.Error())
+	})
+	engine.Get("/error", E(func(ctx *Context) error {
+		return errors.New("boom")
+	}))
+	engine.Get("/panic", func(ctx *Context) {
+		panic("oops")
+	})
+
+	engine.Test(httptest.NewRequest(http.MethodGet, "/error", nil))
+	engine.Test(httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if len(errs) != 2 || errs[0] != "/error boom" || errs[1] != "/panic panic: oops" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	var events []string
+	engine := New()
+	started := make(chan struct{})
+	engine.OnStart(func() error {
+		events = append(events, "start")
+		close(started)
+		return nil
+	})
+	engine.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "shutdown 1")
+		return nil
+	})
+	engine.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "shutdown 2")
+		return errors.New("flush failed")
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.Run("127.0.0.1:0")
+	}()
+	<-started
+
+	// 等待服务开始监听
+	time.Sleep(20 * time.Millisecond)
+	if err := engine.Run("127.0.0.1:0"); err == nil {
+		t.Fatalf("engine should not run twice")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err == nil || err.Error() != "flush failed" {
+		t.Fatalf("shutdown hook errors should be returned, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("run should return nil after shutdown, got %v", err)
+	}
+	if len(events) != 3 || events[1] != "shutdown 2" || events[2] != "shutdown 1" {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	// 启动钩子失败时不启动
+	engine = New()
+	engine.OnStart(func() error {
+		return errors.New("migrate failed")
+	})
+	if err := engine.Run("127.0.0.1:0"); err == nil || err.Error() != "gee: start hook: migrate failed" {
+		t.Fatalf("start hook error should abort run, got %v", err)
+	}
+
+	// 端口被占用时返回监听错误，之后仍然可以再次启动
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	engine = New()
+	for i := 0; i < 2; i++ {
+		if err := engine.Run(listener.Addr().String()); err == nil || strings.Contains(err.Error(), "already running") {
+			t.Fatalf("listen error should be returned, got %v", err)
+		}
+	}
+}
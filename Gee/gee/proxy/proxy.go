@@ -0,0 +1,325 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gee-demo/gee"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/* ---------------------------------- 反向代理 ---------------------------------- */
+/**
+ * 将请求转发到一组上游服务：
+ *  负载均衡：轮询或最少连接，跳过健康检查失败的上游
+ *  路径重写：Rewrite 中的 :name、*name 使用路由参数替换，如 /api/v1/*path
+ *  重试：幂等的请求(GET/HEAD/OPTIONS/PUT/DELETE)在连接失败或返回 502/503/504 时换一个上游重试，
+ *        重试耗尽时返回最后一个上游的响应
+ *  X-Forwarded-For 追加客户端地址，X-Forwarded-Host、X-Forwarded-Proto 设置为当前请求的值
+ * 不支持 Upgrade(WebSocket) 请求
+ */
+
+type Config struct {
+	// 上游地址，如 http://10.0.0.1:8080
+	Upstreams []string
+	Balancer  Balancer
+	// 转发的路径模板，为空时使用原始路径
+	Rewrite string
+	// 幂等请求的最大重试次数
+	Retries int
+	// 重试时缓冲的请求体上限，默认 1MB，超出时直接转发且不重试
+	MaxBodySize int64
+	// 为 true 时保留请求的 Host，否则使用上游的 Host
+	PreserveHost bool
+	HealthCheck  HealthCheck
+	// 默认 http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+type Proxy struct {
+	config    Config
+	upstreams []*Upstream
+	// 轮询的计数
+	next atomic.Uint64
+	done chan struct{}
+	once sync.Once
+}
+
+// 所有上游都不可用
+var ErrNoUpstream = errors.New("proxy: no healthy upstream")
+
+// 根据配置实例化一个反向代理，开启健康检查时需要调用 Close 停止
+func New(config Config) (*Proxy, error) {
+	if len(config.Upstreams) == 0 {
+		return nil, errors.New("proxy: no upstreams")
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+	if config.HealthCheck.Interval <= 0 {
+		config.HealthCheck.Interval = 10 * time.Second
+	}
+	if config.HealthCheck.Timeout <= 0 {
+		config.HealthCheck.Timeout = 2 * time.Second
+	}
+
+	proxy := &Proxy{config: config, done: make(chan struct{})}
+	for _, rawURL := range config.Upstreams {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("proxy: invalid upstream %q", rawURL)
+		}
+		upstream := &Upstream{URL: u}
+		upstream.healthy.Store(true)
+		proxy.upstreams = append(proxy.upstreams, upstream)
+	}
+
+	if config.HealthCheck.Path != "" {
+		go proxy.healthCheckLoop()
+	}
+
+	return proxy, nil
+}
+
+// 停止健康检查
+func (proxy *Proxy) Close() {
+	proxy.once.Do(func() {
+		close(proxy.done)
+	})
+}
+
+// 所有上游
+func (proxy *Proxy) Upstreams() []*Upstream {
+	return proxy.upstreams
+}
+
+func (proxy *Proxy) Handler() gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		idempotent := isIdempotent(ctx.Method)
+		retries := 0
+		if idempotent {
+			retries = proxy.config.Retries
+		}
+
+		// 需要重试时，缓冲请求体以便重新发送
+		var body []byte
+		if retries > 0 && ctx.Req.Body != nil && ctx.Req.Body != http.NoBody {
+			buffered, err := io.ReadAll(io.LimitReader(ctx.Req.Body, proxy.config.MaxBodySize+1))
+			if err != nil {
+				ctx.FatalT(http.StatusBadRequest, "gee.bad_request")
+				return
+			}
+			if int64(len(buffered)) > proxy.config.MaxBodySize {
+				// 请求体过大，拼接已读取的部分直接转发，不再重试
+				retries = 0
+				ctx.Req.Body = readCloser{io.MultiReader(bytes.NewReader(buffered), ctx.Req.Body), ctx.Req.Body}
+			} else {
+				body = buffered
+			}
+		}
+
+		path := proxy.rewrite(ctx)
+		tried := make(map[*Upstream]bool)
+		var lastErr error
+		// 最后一个需要重试的响应(502/503/504)，重试耗尽时原样返回，保留 Retry-After 等响应头
+		var lastRes *http.Response
+		defer func() {
+			if lastRes != nil {
+				lastRes.Body.Close()
+			}
+		}()
+
+		for attempt := 0; attempt <= retries; attempt++ {
+			upstream := proxy.pick(tried)
+			if upstream == nil {
+				break
+			}
+			tried[upstream] = true
+
+			out := proxy.outgoing(ctx, upstream, path)
+			if body != nil {
+				out.Body = io.NopCloser(bytes.NewReader(body))
+				out.ContentLength = int64(len(body))
+			}
+
+			upstream.active.Add(1)
+			res, err := proxy.config.Transport.RoundTrip(out)
+			if err != nil {
+				upstream.active.Add(-1)
+				lastErr = err
+				// 客户端已断开
+				if ctx.Req.Context().Err() != nil {
+					return
+				}
+				continue
+			}
+			if attempt < retries && retryableStatus(res.StatusCode) {
+				upstream.active.Add(-1)
+				if lastRes != nil {
+					lastRes.Body.Close()
+				}
+				lastRes = res
+				continue
+			}
+
+			proxy.copyResponse(ctx, res)
+			upstream.active.Add(-1)
+			return
+		}
+
+		if lastRes != nil {
+			proxy.copyResponse(ctx, lastRes)
+			return
+		}
+		if lastErr == nil {
+			lastErr = ErrNoUpstream
+			ctx.FatalT(http.StatusServiceUnavailable, "gee.service_unavailable")
+		} else {
+			ctx.FatalT(http.StatusBadGateway, "gee.bad_gateway")
+		}
+		log.Printf("[E - Proxy] %s %s: %v", ctx.Method, ctx.Req.RequestURI, lastErr)
+	}
+}
+
+// 使用路由参数替换 Rewrite 中的 :name、*name
+func (proxy *Proxy) rewrite(ctx *gee.Context) string {
+	if proxy.config.Rewrite == "" {
+		return ctx.Req.URL.Path
+	}
+
+	parts := strings.Split(proxy.config.Rewrite, "/")
+	for i, part := range parts {
+		if part != "" && (part[0] == ':' || part[0] == '*') {
+			parts[i] = ctx.Param(part[1:])
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// 构造转发的请求
+func (proxy *Proxy) outgoing(ctx *gee.Context, upstream *Upstream, path string) *http.Request {
+	req := ctx.Req
+	out := req.Clone(req.Context())
+	out.RequestURI = ""
+	out.URL.Scheme = upstream.URL.Scheme
+	out.URL.Host = upstream.URL.Host
+	out.URL.Path = joinPath(upstream.URL.Path, path)
+	out.URL.RawPath = ""
+	if !proxy.config.PreserveHost {
+		out.Host = upstream.URL.Host
+	}
+	out.Close = false
+
+	removeHopHeaders(out.Header)
+
+	if ip := ctx.RemoteIP(); ip != "" {
+		if prior := req.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+			ip = strings.Join(prior, ", ") + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+	out.Header.Set("X-Forwarded-Host", req.Host)
+	if req.TLS != nil {
+		out.Header.Set("X-Forwarded-Proto", "https")
+	} else {
+		out.Header.Set("X-Forwarded-Proto", "http")
+	}
+
+	return out
+}
+
+// 将上游的响应写回客户端
+func (proxy *Proxy) copyResponse(ctx *gee.Context, res *http.Response) {
+	defer res.Body.Close()
+
+	removeHopHeaders(res.Header)
+	header := ctx.Res.Header()
+	for key, values := range res.Header {
+		header[key] = values
+	}
+	ctx.Status(res.StatusCode)
+
+	// 流式响应需要及时刷新
+	flusher, ok := ctx.Res.(http.Flusher)
+	if !ok || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		io.Copy(ctx.Res, res.Body)
+		return
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := res.Body.Read(buf)
+		if n > 0 {
+			ctx.Res.Write(buf[:n])
+			flusher.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// 逐跳的请求头，不能转发
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+func joinPath(base string, path string) string {
+	switch {
+	case base == "" || base == "/":
+		if !strings.HasPrefix(path, "/") {
+			return "/" + path
+		}
+		return path
+	case path == "":
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"fmt"
+	"gee-demo/gee"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 返回自身名称与收到的请求信息的上游
+func newUpstream(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Upstream", name)
+		fmt.Fprintf(w, "%s %s %s|%s|%s|%s", name, r.Method, r.URL.RequestURI(), r.Header.Get("X-Forwarded-For"), r.Header.Get("X-Forwarded-Host"), body)
+	}))
+}
+
+func TestRoundRobinAndRewrite(t *testing.T) {
+	a, b := newUpstream("a"), newUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	proxy, err := New(Config{Upstreams: []string{a.URL, b.URL + "/base"}, Rewrite: "/v1/users/:id/*rest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := gee.New()
+	engine.Get("/users/:id/*rest", proxy.Handler())
+
+	expects := []string{
+		"a GET /v1/users/1/posts/2?x=1|192.0.2.1|example.com|",
+		"b GET /base/v1/users/1/posts/2?x=1|192.0.2.1|example.com|",
+		"a GET /v1/users/1/posts/2?x=1|192.0.2.1|example.com|",
+	}
+	for _, expect := range expects {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "http://example.com/users/1/posts/2?x=1", nil))
+		if res.Body.String() != expect {
+			t.Fatalf("expect %q, but got %q", expect, res.Body.String())
+		}
+	}
+
+	// 追加到已有的 X-Forwarded-For
+	req := httptest.NewRequest(http.MethodGet, "/users/1/a", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("Connection", "X-Secret")
+	req.Header.Set("X-Secret", "1")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if !strings.Contains(res.Body.String(), "|203.0.113.9, 192.0.2.1|") {
+		t.Fatalf("X-Forwarded-For should be appended, got %q", res.Body.String())
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var failed int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&failed, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	good := newUpstream("good")
+	defer bad.Close()
+	defer good.Close()
+
+	proxy, _ := New(Config{Upstreams: []string{bad.URL, good.URL}, Retries: 1})
+	engine := gee.New()
+	engine.Get("/*path", proxy.Handler())
+	engine.Post("/*path", proxy.Handler())
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/a", nil))
+		if res.Code != http.StatusOK || res.Header().Get("X-Upstream") != "good" {
+			t.Fatalf("get should be retried, got %d", res.Code)
+		}
+	}
+
+	// 非幂等请求不重试，直接返回上游的响应
+	codes := make(map[int]int)
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/a", strings.NewReader("body")))
+		codes[res.Code]++
+	}
+	if codes[http.StatusOK] != 1 || codes[http.StatusServiceUnavailable] != 1 {
+		t.Fatalf("post should not be retried, got %v", codes)
+	}
+
+	// 无法连接的上游
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	proxy, _ = New(Config{Upstreams: []string{closed.URL}, Retries: 2})
+	engine = gee.New()
+	engine.Get("/", proxy.Handler())
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusBadGateway {
+		t.Fatalf("expect 502, but got %d", res.Code)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "busy")
+	}))
+	defer busy.Close()
+
+	// 只有一个上游，重试次数用不完
+	proxy, _ := New(Config{Upstreams: []string{busy.URL}, Retries: 2})
+	engine := gee.New()
+	engine.Get("/", proxy.Handler())
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusServiceUnavailable || res.Header().Get("Retry-After") != "30" || res.Body.String() != "busy" {
+		t.Fatalf("last upstream response should be returned, got %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	good := newUpstream("good")
+	defer bad.Close()
+	defer good.Close()
+
+	proxy, _ := New(Config{Upstreams: []string{bad.URL, good.URL}, Retries: 1})
+	engine := gee.New()
+	engine.Get("/", proxy.Handler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader("payload"))
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if !strings.HasSuffix(res.Body.String(), "|payload") {
+		t.Fatalf("body should be replayed, got %q", res.Body.String())
+	}
+}
+
+func TestRetryBodyLimit(t *testing.T) {
+	var attempts int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write(body)
+	}))
+	defer bad.Close()
+
+	proxy, _ := New(Config{Upstreams: []string{bad.URL, bad.URL}, Retries: 1, MaxBodySize: 4})
+	engine := gee.New()
+	engine.Put("/", proxy.Handler())
+
+	// 超出上限的请求体完整转发，但不重试
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+	if res.Code != http.StatusBadGateway || res.Body.String() != "payload" || atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("large bodies should not be retried, got %d after %d attempts", res.Code, attempts)
+	}
+}
+
+func TestLeastConn(t *testing.T) {
+	a, b := newUpstream("a"), newUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	proxy, _ := New(Config{Upstreams: []string{a.URL, b.URL}, Balancer: LeastConn})
+	proxy.upstreams[0].active.Add(5)
+
+	for i := 0; i < 3; i++ {
+		if picked := proxy.pick(map[*Upstream]bool{}); picked != proxy.upstreams[1] {
+			t.Fatalf("upstream with least connections should be picked")
+		}
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Upstream", "a")
+	}))
+	b := newUpstream("b")
+	defer a.Close()
+	defer b.Close()
+
+	// 不开启后台检查，手动触发
+	proxy, _ := New(Config{Upstreams: []string{a.URL, b.URL}})
+	proxy.config.HealthCheck.Path = "/health"
+
+	healthy.Store(false)
+	proxy.checkAll()
+	if proxy.upstreams[0].Healthy() || !proxy.upstreams[1].Healthy() {
+		t.Fatalf("unexpected health state")
+	}
+
+	engine := gee.New()
+	engine.Get("/", proxy.Handler())
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+		if res.Header().Get("X-Upstream") != "b" {
+			t.Fatalf("unhealthy upstream should be skipped")
+		}
+	}
+
+	proxy.upstreams[1].healthy.Store(false)
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 without healthy upstreams, got %d", res.Code)
+	}
+}
+
+func TestHealthCheckLoop(t *testing.T) {
+	a := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer a.Close()
+
+	proxy, _ := New(Config{Upstreams: []string{a.URL}, HealthCheck: HealthCheck{Path: "/health", Interval: time.Hour}})
+	defer proxy.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for proxy.upstreams[0].Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream should be marked unhealthy by the first check")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/* ---------------------------------- 上游服务 ---------------------------------- */
+
+// 负载均衡策略
+type Balancer int
+
+const (
+	// 轮询
+	RoundRobin Balancer = iota
+	// 最少连接
+	LeastConn
+)
+
+type Upstream struct {
+	URL *url.URL
+	// 健康检查的结果，未开启健康检查时始终健康
+	healthy atomic.Bool
+	// 正在处理的请求数
+	active atomic.Int64
+}
+
+// 是否健康
+func (upstream *Upstream) Healthy() bool {
+	return upstream.healthy.Load()
+}
+
+// 正在处理的请求数
+func (upstream *Upstream) Active() int64 {
+	return upstream.active.Load()
+}
+
+// 从健康且未尝试过的上游中选择一个
+func (proxy *Proxy) pick(tried map[*Upstream]bool) *Upstream {
+	n := len(proxy.upstreams)
+	start := int(proxy.next.Add(1)-1) % n
+
+	var picked *Upstream
+	for i := 0; i < n; i++ {
+		upstream := proxy.upstreams[(start+i)%n]
+		if tried[upstream] || !upstream.Healthy() {
+			continue
+		}
+		if proxy.config.Balancer == RoundRobin {
+			return upstream
+		}
+		// 连接数相同时，按轮询的顺序选择
+		if picked == nil || upstream.Active() < picked.Active() {
+			picked = upstream
+		}
+	}
+
+	return picked
+}
+
+/* ---------------------------------- 健康检查 ---------------------------------- */
+
+type HealthCheck struct {
+	// 检查的路径，为空时不检查
+	Path string
+	// 检查间隔，默认 10s
+	Interval time.Duration
+	// 单次检查的超时时间，默认 2s
+	Timeout time.Duration
+}
+
+// 定时检查所有上游，直到 Close
+func (proxy *Proxy) healthCheckLoop() {
+	ticker := time.NewTicker(proxy.config.HealthCheck.Interval)
+	defer ticker.Stop()
+
+	proxy.checkAll()
+	for {
+		select {
+		case <-ticker.C:
+			proxy.checkAll()
+		case <-proxy.done:
+			return
+		}
+	}
+}
+
+func (proxy *Proxy) checkAll() {
+	var wg sync.WaitGroup
+	for _, upstream := range proxy.upstreams {
+		wg.Add(1)
+		go func(upstream *Upstream) {
+			defer wg.Done()
+			upstream.healthy.Store(proxy.check(upstream))
+		}(upstream)
+	}
+	wg.Wait()
+}
+
+// 2xx/3xx 视为健康
+func (proxy *Proxy) check(upstream *Upstream) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), proxy.config.HealthCheck.Timeout)
+	defer cancel()
+
+	target := *upstream.URL
+	target.Path = joinPath(target.Path, proxy.config.HealthCheck.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := proxy.config.Transport.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+
+	return res.StatusCode >= 200 && res.StatusCode < 400
+}
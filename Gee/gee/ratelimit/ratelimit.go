@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"gee-demo/gee"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/* ---------------------------------- 限流中间件 ---------------------------------- */
+
+// 从请求中提取限流的 key，返回空字符串时不限流
+type KeyFunc func(ctx *gee.Context) string
+
+type Config struct {
+	Rule
+	// 限流状态存储，默认使用 MemoryStore
+	Store Store
+	// 限流的 key，默认按客户端 IP
+	KeyFunc KeyFunc
+	// 被限流时的处理函数，默认返回 429
+	LimitHandler gee.HandlerFunc
+}
+
+// 令牌桶限流：每个客户端 IP 每 window 最多 limit 次，允许突发
+func PerIP(limit int, window time.Duration) gee.HandlerFunc {
+	return New(Config{Rule: Rule{Algorithm: TokenBucket, Limit: limit, Window: window}})
+}
+
+// 根据配置实例化一个限流中间件
+func New(config Config) gee.HandlerFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic("ratelimit: Limit and Window must be positive")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore(defaultCapacity)
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = ByIP()
+	}
+	if config.LimitHandler == nil {
+		config.LimitHandler = func(ctx *gee.Context) {
+			ctx.FatalT(http.StatusTooManyRequests, "gee.too_many_requests")
+		}
+	}
+
+	return func(ctx *gee.Context) {
+		key := config.KeyFunc(ctx)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		now := time.Now()
+		result, err := config.Store.Take(key, config.Rule, now)
+		if err != nil {
+			// 存储不可用时放行，避免限流组件拖垮整个服务
+			log.Printf("[M - RateLimit] store error: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.SetHeader("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.SetHeader("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.SetHeader("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+		if !result.Allowed {
+			ctx.SetHeader("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			config.LimitHandler(ctx)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+/* ---------------------------------- 限流 key ---------------------------------- */
+
+// 按客户端 IP 限流，位于代理之后时需要设置 engine.SetTrustedProxies
+func ByIP() KeyFunc {
+	return func(ctx *gee.Context) string {
+		return "ip:" + ctx.ClientIP()
+	}
+}
+
+// 按请求头限流，如 X-API-Key，请求头不存在时不限流
+func ByHeader(name string) KeyFunc {
+	return func(ctx *gee.Context) string {
+		if value := ctx.Req.Header.Get(name); value != "" {
+			return "header:" + name + ":" + value
+		}
+		return ""
+	}
+}
+
+// 按路由限流，同一路由规则下的所有请求共享配额
+func ByRoute() KeyFunc {
+	return func(ctx *gee.Context) string {
+		if ctx.Pattern == "" {
+			return ""
+		}
+		return "route:" + ctx.Method + "-" + ctx.Pattern
+	}
+}
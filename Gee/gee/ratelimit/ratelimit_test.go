@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	store := NewMemoryStore(0)
+	rule := Rule{Algorithm: TokenBucket, Limit: 2, Window: 2 * time.Second}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take("k", rule, now); !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("request %d should be allowed, result %+v", i, result)
+		}
+	}
+	result, _ := store.Take("k", rule, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("bucket should be empty, result %+v", result)
+	}
+
+	// 1 秒补充 1 个令牌
+	if result, _ := store.Take("k", rule, now.Add(time.Second)); !result.Allowed {
+		t.Fatalf("token should be refilled, result %+v", result)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	store := NewMemoryStore(0)
+	rule := Rule{Algorithm: SlidingWindow, Limit: 4, Window: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 4; i++ {
+		if result, _ := store.Take("k", rule, now); !result.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if result, _ := store.Take("k", rule, now); result.Allowed || result.Remaining != 0 {
+		t.Fatalf("window should be full, result %+v", result)
+	}
+
+	// 下一个窗口过去一半，上一个窗口的 4 次按一半计算
+	next := now.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Take("k", rule, next); !result.Allowed {
+			t.Fatalf("request %d in next window should be allowed", i)
+		}
+	}
+	if result, _ := store.Take("k", rule, next); result.Allowed || result.RetryAfter != 15*time.Second {
+		t.Fatalf("weighted window should be full, result %+v", result)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	store := NewMemoryStore(2)
+	rule := Rule{Algorithm: TokenBucket, Limit: 1, Window: time.Second}
+	now := time.Now()
+
+	store.Take("a", rule, now)
+	store.Take("b", rule, now)
+	store.Take("c", rule, now)
+	if store.Len() != 2 {
+		t.Fatalf("store should evict oldest key, len %d", store.Len())
+	}
+
+	// 超过清理间隔，过期状态被清理
+	store.Take("d", rule, now.Add(2*sweepInterval))
+	if store.Len() != 1 {
+		t.Fatalf("expired keys should be swept, len %d", store.Len())
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	engine := gee.New()
+	engine.Use(New(Config{
+		Rule:    Rule{Algorithm: TokenBucket, Limit: 1, Window: time.Minute},
+		KeyFunc: ByRoute(),
+	}))
+	engine.Get("/expensive/:id", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/expensive/1", nil))
+	if res.Code != http.StatusOK || res.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("first request should pass, status %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/expensive/2", nil))
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request on same route should be limited, status %d", res.Code)
+	}
+	if retry, _ := strconv.Atoi(res.Header().Get("Retry-After")); retry != 60 {
+		t.Fatalf("retry after expect 60, but got %q", res.Header().Get("Retry-After"))
+	}
+	if res.Header().Get("X-RateLimit-Limit") != "1" || res.Header().Get("X-RateLimit-Reset") == "" {
+		t.Fatalf("rate limit headers missing: %v", res.Header())
+	}
+}
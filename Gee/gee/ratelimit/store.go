@@ -0,0 +1,248 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 限流存储 ---------------------------------- */
+/**
+ * Store 负责保存每个 key 的限流状态，并原子地完成一次"检查 + 消耗"
+ * 内置的 MemoryStore 只在单机内生效，多实例部署时可以实现基于 Redis 等的共享 Store
+ */
+
+type Store interface {
+	// 按照 rule 为 key 消耗一次配额
+	Take(key string, rule Rule, now time.Time) (Result, error)
+}
+
+// 限流算法
+type Algorithm int
+
+const (
+	// 令牌桶：桶容量为 Limit，每 Window 匀速补满，允许突发
+	TokenBucket Algorithm = iota
+	// 滑动窗口：任意 Window 时间内最多 Limit 次请求（加权计数近似）
+	SlidingWindow
+)
+
+// 限流规则
+type Rule struct {
+	Algorithm Algorithm
+	Limit     int
+	Window    time.Duration
+}
+
+// 一次消耗的结果
+type Result struct {
+	// 是否放行
+	Allowed bool
+	// 配额上限
+	Limit int
+	// 剩余配额
+	Remaining int
+	// 配额重置的时间
+	Reset time.Time
+	// 被拒绝时，建议多久之后重试
+	RetryAfter time.Duration
+}
+
+/* ---------------------------------- 内存存储 ---------------------------------- */
+
+// 默认的最大 key 数量
+const defaultCapacity = 10000
+
+// 清理过期状态的间隔
+const sweepInterval = time.Minute
+
+type MemoryStore struct {
+	mutex sync.Mutex
+	// 最多保存的 key 数量，超出时淘汰最久未访问的 key
+	capacity int
+	// 双向队列，front 为最近访问
+	list    *list.List
+	entries map[string]*list.Element
+	// 上一次清理过期状态的时间
+	lastSweep time.Time
+}
+
+type entry struct {
+	key       string
+	algorithm Algorithm
+	// 令牌桶：剩余令牌数、上次补充时间
+	tokens float64
+	last   time.Time
+	// 滑动窗口：当前窗口开始时间、上一个窗口与当前窗口的计数
+	windowStart time.Time
+	prevCount   int
+	currCount   int
+	// 状态过期时间，过期后等价于初始状态，可以直接删除
+	expireAt time.Time
+}
+
+// 实例化一个内存存储，capacity <= 0 时使用默认容量
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+
+	return &MemoryStore{
+		capacity: capacity,
+		list:     list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (store *MemoryStore) Take(key string, rule Rule, now time.Time) (Result, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if now.Sub(store.lastSweep) > sweepInterval {
+		store.sweep(now)
+	}
+
+	var e *entry
+	if ele, ok := store.entries[key]; ok {
+		store.list.MoveToFront(ele)
+		e = ele.Value.(*entry)
+		// 状态过期或算法变更，重新开始
+		if e.algorithm != rule.Algorithm || !now.Before(e.expireAt) {
+			e.reset(rule, now)
+		}
+	} else {
+		e = &entry{key: key}
+		e.reset(rule, now)
+		store.entries[key] = store.list.PushFront(e)
+		// 容量不足，淘汰最久未访问的 key
+		for store.list.Len() > store.capacity {
+			store.removeElement(store.list.Back())
+		}
+	}
+
+	if rule.Algorithm == SlidingWindow {
+		return e.takeWindow(rule, now), nil
+	}
+	return e.takeToken(rule, now), nil
+}
+
+// 当前保存的 key 数量
+func (store *MemoryStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.list.Len()
+}
+
+// 清理过期的状态
+func (store *MemoryStore) sweep(now time.Time) {
+	store.lastSweep = now
+
+	for ele := store.list.Back(); ele != nil; {
+		prev := ele.Prev()
+		if !now.Before(ele.Value.(*entry).expireAt) {
+			store.removeElement(ele)
+		}
+		ele = prev
+	}
+}
+
+func (store *MemoryStore) removeElement(ele *list.Element) {
+	store.list.Remove(ele)
+	delete(store.entries, ele.Value.(*entry).key)
+}
+
+func (e *entry) reset(rule Rule, now time.Time) {
+	e.algorithm = rule.Algorithm
+	e.tokens = float64(rule.Limit)
+	e.last = now
+	e.windowStart = now
+	e.prevCount = 0
+	e.currCount = 0
+	e.expireAt = now
+}
+
+// 令牌桶
+func (e *entry) takeToken(rule Rule, now time.Time) Result {
+	// 每秒补充的令牌数
+	rate := float64(rule.Limit) / rule.Window.Seconds()
+	limit := float64(rule.Limit)
+
+	e.tokens = math.Min(limit, e.tokens+now.Sub(e.last).Seconds()*rate)
+	e.last = now
+
+	result := Result{Limit: rule.Limit}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - e.tokens) / rate)
+	}
+
+	// 令牌补满的时间
+	full := seconds((limit - e.tokens) / rate)
+	result.Remaining = int(e.tokens)
+	result.Reset = now.Add(full)
+	e.expireAt = result.Reset
+
+	return result
+}
+
+// 滑动窗口：按照当前窗口已经过去的比例，对上一个窗口的计数加权
+func (e *entry) takeWindow(rule Rule, now time.Time) Result {
+	window := rule.Window
+
+	// 窗口滑动
+	if elapsed := now.Sub(e.windowStart); elapsed >= window {
+		if elapsed >= 2*window {
+			e.prevCount = 0
+		} else {
+			e.prevCount = e.currCount
+		}
+		e.currCount = 0
+		e.windowStart = e.windowStart.Add(elapsed / window * window)
+	}
+
+	elapsed := now.Sub(e.windowStart)
+	weight := 1 - float64(elapsed)/float64(window)
+	estimate := float64(e.prevCount)*weight + float64(e.currCount)
+
+	result := Result{Limit: rule.Limit, Reset: e.windowStart.Add(window)}
+	if estimate+1 <= float64(rule.Limit) {
+		e.currCount++
+		estimate++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.retryAfter(rule, elapsed)
+	}
+
+	result.Remaining = int(math.Max(0, float64(rule.Limit)-math.Ceil(estimate)))
+	// 两个窗口之后计数全部失效
+	e.expireAt = e.windowStart.Add(2 * window)
+
+	return result
+}
+
+// 估算下一次请求可以被放行的时间
+func (e *entry) retryAfter(rule Rule, elapsed time.Duration) time.Duration {
+	window := float64(rule.Window)
+	limit := float64(rule.Limit)
+
+	// 当前窗口内，等待上一个窗口的权重下降
+	if free := limit - float64(e.currCount) - 1; free >= 0 && e.prevCount > 0 {
+		at := window * (1 - free/float64(e.prevCount))
+		return time.Duration(at) - elapsed
+	}
+
+	// 等到下一个窗口，当前窗口成为上一个窗口
+	wait := rule.Window - elapsed
+	if e.currCount > 0 {
+		wait += time.Duration(math.Max(0, window*(1-(limit-1)/float64(e.currCount))))
+	}
+	return wait
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
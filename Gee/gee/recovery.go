@@ -28,12 +28,18 @@ func Recovery() HandlerFunc {
 	return func(ctx *Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				message := fmt.Sprintf("%s", err)
-				log.Printf("[E - Panic] [500] %s\n\n", trace(message))
-				ctx.Fatal(http.StatusInternalServerError, "Internal Server Error")
+				ctx.reportPanic(err)
+				ctx.FatalT(http.StatusInternalServerError, "gee.internal_error")
 			}
 		}()
 
 		ctx.Next()
 	}
+}
+
+// 记录panic的堆栈，并通知 OnError 钩子
+func (ctx *Context) reportPanic(err interface{}) {
+	message := fmt.Sprintf("%s", err)
+	log.Printf("[E - Panic] [500] %s\n\n", trace(message))
+	ctx.notifyError(fmt.Errorf("panic: %s", message))
 }
\ No newline at end of file
@@ -0,0 +1,78 @@
+package gee
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+/* ---------------------------------- 请求ID ---------------------------------- */
+/**
+ * 读取请求头中的 X-Request-ID，不存在或不合法时生成一个新的
+ * 请求ID会存入 ctx 的键值存储(RequestIDKey)，并写回响应头，便于关联日志与下游调用
+ */
+
+const (
+	// 请求ID在键值存储中的key
+	RequestIDKey = "gee.requestID"
+	// 默认的请求头
+	RequestIDHeader = "X-Request-ID"
+)
+
+type RequestIDConfig struct {
+	// 请求ID所在的请求头，默认 X-Request-ID
+	Header string
+	// 生成请求ID，默认生成32位十六进制随机字符串
+	Generator func() string
+}
+
+// 请求ID中间件
+func RequestID() HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// 根据配置实例化一个请求ID中间件
+func RequestIDWithConfig(config RequestIDConfig) HandlerFunc {
+	if config.Header == "" {
+		config.Header = RequestIDHeader
+	}
+	if config.Generator == nil {
+		config.Generator = generateRequestID
+	}
+
+	return func(ctx *Context) {
+		id := ctx.Req.Header.Get(config.Header)
+		if !validRequestID(id) {
+			id = config.Generator()
+		}
+
+		ctx.Set(RequestIDKey, id)
+		ctx.SetHeader(config.Header, id)
+		ctx.Next()
+	}
+}
+
+// 获取当前请求的请求ID
+func (ctx *Context) RequestID() string {
+	return ctx.GetString(RequestIDKey)
+}
+
+func generateRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// 不信任过长或包含不可见字符的请求ID，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
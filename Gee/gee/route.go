@@ -0,0 +1,47 @@
+package gee
+
+/* ---------------------------------- 路由信息 ---------------------------------- */
+/**
+ * 注册路由时返回 *Route，可以通过 Doc 附加文档信息，用于生成 OpenAPI 文档：
+ *  engine.Get("/users/:id", getUser).Doc(gee.RouteDoc{Summary: "查询用户", Response: User{}})
+ */
+
+// 已注册的路由
+type Route struct {
+	Method  string
+	Pattern string
+	Handler HandlerFunc
+	// 路由的文档信息
+	Meta RouteDoc
+}
+
+// 路由的文档信息
+type RouteDoc struct {
+	// 简介
+	Summary string
+	// 详细描述
+	Description string
+	// 分组标签
+	Tags []string
+	// 请求参数的绑定结构体，GET/DELETE 使用 form 标签作为 Query 参数，其他方法作为 JSON 请求体
+	Request interface{}
+	// 成功响应的结构体
+	Response interface{}
+	// 成功响应的状态码，默认 200
+	Status int
+	// 是否已废弃
+	Deprecated bool
+}
+
+// 设置路由的文档信息
+func (route *Route) Doc(doc RouteDoc) *Route {
+	route.Meta = doc
+	return route
+}
+
+// 按注册顺序返回所有路由
+func (engine *Engine) Routes() []*Route {
+	routes := make([]*Route, len(engine.router.routes))
+	copy(routes, engine.router.routes)
+	return routes
+}
@@ -1,9 +1,9 @@
 package gee
 
 import (
-	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +16,8 @@ type Router struct {
 	roots	map[string]*node
 	// 通过键值对查找对应的路由和HandleFunc
 	handlers map[string]HandlerFunc
+	// 按注册顺序记录的路由
+	routes []*Route
 }
 
 // 实例化路由器
@@ -45,7 +47,7 @@ func parsePattern(pattern string) []string {
 }
 
 // 添加路由
-func (router *Router) addRoute(method string, pattern string, handler HandlerFunc) {
+func (router *Router) addRoute(method string, pattern string, handler HandlerFunc) *Route {
 	log.Printf("Register Route %4s - %s", method, pattern)
 
 	// 解析pattern
@@ -61,6 +63,10 @@ func (router *Router) addRoute(method string, pattern string, handler HandlerFun
 	router.roots[method].insert(pattern, parts, 0)
 	// 添加对应的handler
 	router.handlers[key] = handler
+
+	route := &Route{Method: method, Pattern: pattern, Handler: handler}
+	router.routes = append(router.routes, route)
+	return route
 }
 
 // 获取路由
@@ -105,16 +111,40 @@ func (router *Router) handler(ctx *Context) {
 	if route != nil {
 		// 给ctx传入params
 		ctx.Params = params
+		ctx.Pattern = route.pattern
 		key := ctx.Method + "-" + route.pattern
 
 		// 将路由处理作为最后一个中间件去执行
 		ctx.middlewares = append(ctx.middlewares, router.handlers[key])
+	} else if allowed := router.allowedMethods(ctx); len(allowed) > 0 {
+		// 路径存在但方法不匹配，返回405并列出允许的方法
+		ctx.middlewares = append(ctx.middlewares, func(ctx *Context) {
+			ctx.SetHeader("Allow", strings.Join(allowed, ", "))
+			ctx.renderError(&HTTPError{Code: http.StatusMethodNotAllowed, Detail: "gee.method_not_allowed", args: H{"method": ctx.Method, "path": ctx.Path}})
+		})
 	} else {
+		// 未匹配的请求不是 handler 的错误，不触发 OnError 钩子
 		ctx.middlewares = append(ctx.middlewares, func(ctx *Context) {
-			ctx.Fatal(http.StatusNotFound, fmt.Sprintf("404 NOT FOUND: %s", ctx.Path))
+			ctx.renderError(&HTTPError{Code: http.StatusNotFound, Detail: "gee.not_found", args: H{"path": ctx.Path}})
 		})
 	}
 
 	// 开始执行所有中间件
 	ctx.Next()
+}
+
+// 能够匹配path的所有方法，未开启 HandleMethodNotAllowed 时返回空
+func (router *Router) allowedMethods(ctx *Context) []string {
+	methods := make([]string, 0)
+	if ctx.engine == nil || !ctx.engine.HandleMethodNotAllowed {
+		return methods
+	}
+	for method := range router.roots {
+		if route, _ := router.getRoute(method, ctx.Path); route != nil {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+
+	return methods
 }
\ No newline at end of file
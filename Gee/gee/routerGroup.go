@@ -3,6 +3,7 @@ package gee
 import (
 	"net/http"
 	"path"
+	"strings"
 )
 
 type RouterGroup struct {
@@ -35,20 +36,40 @@ func (routerGroup *RouterGroup) Use(middlewares ...HandlerFunc) {
 }
 
 // 分组上添加路由
-func (routerGroup *RouterGroup) addRoute(method string, pattern string, handler HandlerFunc) {
+func (routerGroup *RouterGroup) addRoute(method string, pattern string, handler HandlerFunc) *Route {
 	compositionPattern := routerGroup.prefix + pattern
 
 	// log.Printf("Register Route %4s - %s", method, compositionPattern)
 
-	routerGroup.engine.router.addRoute(method, compositionPattern, handler)
+	route := routerGroup.engine.router.addRoute(method, compositionPattern, handler)
+	routerGroup.engine.routeRegistered(route)
+
+	return route
+}
+
+func (routerGroup *RouterGroup) Get(pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute("GET", pattern, handler)
+}
+
+func (routerGroup *RouterGroup) Post(pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute("POST", pattern, handler)
+}
+
+func (routerGroup *RouterGroup) Put(pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute("PUT", pattern, handler)
+}
+
+func (routerGroup *RouterGroup) Patch(pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute("PATCH", pattern, handler)
 }
 
-func (routerGroup *RouterGroup) Get(pattern string, handler HandlerFunc) {
-	routerGroup.addRoute("GET", pattern, handler)
+func (routerGroup *RouterGroup) Delete(pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute("DELETE", pattern, handler)
 }
 
-func (routerGroup *RouterGroup) Post(pattern string, handler HandlerFunc) {
-	routerGroup.addRoute("POST", pattern, handler)
+// 注册任意方法的路由
+func (routerGroup *RouterGroup) Handle(method string, pattern string, handler HandlerFunc) *Route {
+	return routerGroup.addRoute(strings.ToUpper(method), pattern, handler)
 }
 
 /* ----------------------------- Static Resource ---------------------------- */
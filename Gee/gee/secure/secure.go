@@ -0,0 +1,188 @@
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"gee-demo/gee"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+/* ---------------------------------- 安全响应头 ---------------------------------- */
+/**
+ * 通过一个配置统一设置常用的安全响应头：
+ *  Strict-Transport-Security   强制浏览器使用 HTTPS(仅在 HTTPS 请求中设置)
+ *  Content-Security-Policy     限制资源来源，策略中的 {nonce} 会替换成每个请求随机生成的 nonce
+ *  X-Frame-Options             禁止被嵌入 iframe，防止点击劫持
+ *  X-Content-Type-Options      禁止浏览器猜测 Content-Type
+ *  Referrer-Policy / Permissions-Policy
+ * 另外支持 HTTP 重定向到 HTTPS，以及校验 Host
+ */
+
+// nonce 在 ctx 键值存储中的key
+const nonceKey = "gee.secure.nonce"
+
+type Config struct {
+	// 允许的 Host，不含端口，为空时不校验
+	AllowedHosts []string
+	// 是否将 HTTP 请求重定向到 HTTPS
+	SSLRedirect bool
+	// 重定向的目标 Host，默认使用请求的 Host
+	SSLHost string
+	// 代理终止 TLS 时，用于判断原始请求是否为 HTTPS，如 {"X-Forwarded-Proto": "https"}
+	SSLProxyHeaders map[string]string
+
+	// HSTS 有效期(秒)，0 表示不设置
+	STSSeconds           int64
+	STSIncludeSubdomains bool
+	STSPreload           bool
+
+	// 如 "default-src 'self'; script-src 'self' 'nonce-{nonce}'"
+	ContentSecurityPolicy string
+	// DENY、SAMEORIGIN
+	FrameOptions       string
+	ContentTypeNosniff bool
+	ReferrerPolicy     string
+	PermissionsPolicy  string
+}
+
+// 推荐的默认配置
+func DefaultConfig() Config {
+	return Config{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		ContentSecurityPolicy: "default-src 'self'",
+		FrameOptions:          "DENY",
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+	}
+}
+
+// 使用默认配置
+func Default() gee.HandlerFunc {
+	return New(DefaultConfig())
+}
+
+// 根据配置实例化一个安全响应头中间件
+func New(config Config) gee.HandlerFunc {
+	sts := ""
+	if config.STSSeconds > 0 {
+		sts = "max-age=" + strconv.FormatInt(config.STSSeconds, 10)
+		if config.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if config.STSPreload {
+			sts += "; preload"
+		}
+	}
+	useNonce := strings.Contains(config.ContentSecurityPolicy, "{nonce}")
+
+	allowedHosts := make(map[string]bool, len(config.AllowedHosts))
+	for _, host := range config.AllowedHosts {
+		allowedHosts[strings.ToLower(hostname(host))] = true
+	}
+
+	return func(ctx *gee.Context) {
+		if len(allowedHosts) > 0 && !allowedHosts[strings.ToLower(hostname(ctx.Req.Host))] {
+			ctx.FatalT(http.StatusBadRequest, "secure.bad_host")
+			return
+		}
+
+		isHTTPS := config.isHTTPS(ctx.Req)
+		if config.SSLRedirect && !isHTTPS {
+			redirect(ctx, config.SSLHost)
+			return
+		}
+
+		header := ctx.Res.Header()
+		if sts != "" && isHTTPS {
+			header.Set("Strict-Transport-Security", sts)
+		}
+		if config.ContentSecurityPolicy != "" {
+			policy := config.ContentSecurityPolicy
+			if useNonce {
+				nonce := newNonce()
+				ctx.Set(nonceKey, nonce)
+				ctx.SetTemplateFunc("cspNonce", func() string { return nonce })
+				policy = strings.ReplaceAll(policy, "{nonce}", nonce)
+			}
+			header.Set("Content-Security-Policy", policy)
+		}
+		if config.FrameOptions != "" {
+			header.Set("X-Frame-Options", config.FrameOptions)
+		}
+		if config.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if config.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", config.ReferrerPolicy)
+		}
+		if config.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", config.PermissionsPolicy)
+		}
+
+		ctx.Next()
+	}
+}
+
+// 获取当前请求的 CSP nonce
+func Nonce(ctx *gee.Context) string {
+	return ctx.GetString(nonceKey)
+}
+
+// 模板函数，需要在 LoadHTMLGlob 之前注册
+//
+//	router.AddFuncMap(secure.FuncMap())
+//	<script nonce="{{ cspNonce }}">...</script>
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"cspNonce": func() string { return "" },
+	}
+}
+
+func (config *Config) isHTTPS(req *http.Request) bool {
+	if req.TLS != nil {
+		return true
+	}
+	for key, value := range config.SSLProxyHeaders {
+		if strings.EqualFold(req.Header.Get(key), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GET/HEAD 使用 301，其他方法使用 308 以保留请求方法与请求体
+func redirect(ctx *gee.Context, sslHost string) {
+	host := sslHost
+	if host == "" {
+		host = hostname(ctx.Req.Host)
+	}
+
+	code := http.StatusPermanentRedirect
+	if ctx.Method == http.MethodGet || ctx.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	ctx.SetHeader("Location", "https://"+host+ctx.Req.URL.RequestURI())
+	ctx.Status(code)
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// 去掉 Host 中的端口
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
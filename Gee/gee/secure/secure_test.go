@@ -0,0 +1,112 @@
+package secure
+
+import (
+	"crypto/tls"
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(`<script nonce="{{ cspNonce }}"></script>`), 0644)
+
+	config := DefaultConfig()
+	config.ContentSecurityPolicy = "script-src 'self' 'nonce-{nonce}'"
+	config.PermissionsPolicy = "geolocation=()"
+
+	engine := gee.New()
+	engine.AddFuncMap(FuncMap())
+	engine.LoadHTMLGlob(filepath.Join(dir, "*"))
+	engine.Use(New(config))
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.HTML(http.StatusOK, "page.tmpl", nil)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	header := res.Header()
+	expects := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Frame-Options":           "DENY",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Permissions-Policy":        "geolocation=()",
+	}
+	for key, expect := range expects {
+		if got := header.Get(key); got != expect {
+			t.Fatalf("%s expect %q, but got %q", key, expect, got)
+		}
+	}
+
+	// 模板中的 nonce 与响应头一致
+	csp := header.Get("Content-Security-Policy")
+	nonce := strings.TrimSuffix(strings.TrimPrefix(csp, "script-src 'self' 'nonce-"), "'")
+	if nonce == "" || nonce == csp || res.Body.String() != `<script nonce="`+nonce+`"></script>` {
+		t.Fatalf("nonce mismatch, csp %q body %q", csp, res.Body.String())
+	}
+
+	// HTTP 请求不设置 HSTS
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	if res.Header().Get("Strict-Transport-Security") != "" {
+		t.Fatalf("hsts should only be sent over https")
+	}
+}
+
+func TestSSLRedirectAndHosts(t *testing.T) {
+	engine := gee.New()
+	engine.Use(New(Config{
+		AllowedHosts:    []string{"example.com"},
+		SSLRedirect:     true,
+		SSLProxyHeaders: map[string]string{"X-Forwarded-Proto": "https"},
+	}))
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	engine.Post("/", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "http://example.com/?a=1", nil))
+	if res.Code != http.StatusMovedPermanently || res.Header().Get("Location") != "https://example.com/?a=1" {
+		t.Fatalf("http get should be redirected, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "http://example.com/", nil))
+	if res.Code != http.StatusPermanentRedirect {
+		t.Fatalf("http post should be redirected with 308, got %d", res.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("https behind proxy should pass, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "https://evil.com/", nil))
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("disallowed host should be rejected, got %d", res.Code)
+	}
+
+	// 比较时忽略端口
+	req = httptest.NewRequest(http.MethodGet, "https://example.com:8443/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("allowed host with port should pass, got %d", res.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+/* ---------------------------------- 安全 Cookie ---------------------------------- */
+/**
+ * cookie 值的格式：base64url(timestamp | payload | hmac)
+ *  payload：设置了加密密钥时为 AES-GCM 加密后的 nonce | ciphertext，否则为明文
+ *  hmac：HMAC-SHA256(name | timestamp | payload)，绑定 cookie 名称，防止被替换到其他 cookie
+ * 密钥轮换：使用第一组密钥编码，解码时依次尝试所有密钥
+ */
+
+var (
+	errInvalidKey   = errors.New("sessions: hash key must not be empty, encryption key must be 16, 24 or 32 bytes")
+	errInvalidValue = errors.New("sessions: invalid cookie value")
+	errExpiredValue = errors.New("sessions: expired cookie value")
+)
+
+const (
+	timestampLen = 8
+	macLen       = sha256.Size
+)
+
+type codec struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// keyPairs 为 hashKey, encryptKey, hashKey, encryptKey...，encryptKey 可以为 nil 表示只签名不加密
+func newCodecs(keyPairs ...[]byte) ([]*codec, error) {
+	codecs := make([]*codec, 0, len(keyPairs)/2+1)
+
+	for i := 0; i < len(keyPairs); i += 2 {
+		if len(keyPairs[i]) == 0 {
+			return nil, errInvalidKey
+		}
+		c := &codec{hashKey: keyPairs[i]}
+
+		if i+1 < len(keyPairs) && keyPairs[i+1] != nil {
+			block, err := aes.NewCipher(keyPairs[i+1])
+			if err != nil {
+				return nil, errInvalidKey
+			}
+			if c.aead, err = cipher.NewGCM(block); err != nil {
+				return nil, err
+			}
+		}
+		codecs = append(codecs, c)
+	}
+
+	return codecs, nil
+}
+
+func (c *codec) encode(name string, value []byte, now time.Time) (string, error) {
+	payload := value
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		// 将 cookie 名称作为附加数据，同样起到绑定作用
+		payload = c.aead.Seal(nonce, nonce, value, []byte(name))
+	}
+
+	b := make([]byte, timestampLen, timestampLen+len(payload)+macLen)
+	binary.BigEndian.PutUint64(b, uint64(now.Unix()))
+	b = append(b, payload...)
+	b = append(b, c.mac(name, b)...)
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// maxAge <= 0 时不检查时间戳
+func (c *codec) decode(name string, value string, maxAge time.Duration, now time.Time) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) < timestampLen+macLen {
+		return nil, errInvalidValue
+	}
+
+	signed, mac := b[:len(b)-macLen], b[len(b)-macLen:]
+	// 使用常量时间比较，防止时序攻击
+	if !hmac.Equal(mac, c.mac(name, signed)) {
+		return nil, errInvalidValue
+	}
+
+	timestamp := time.Unix(int64(binary.BigEndian.Uint64(signed[:timestampLen])), 0)
+	if maxAge > 0 && now.Sub(timestamp) > maxAge {
+		return nil, errExpiredValue
+	}
+
+	payload := signed[timestampLen:]
+	if c.aead == nil {
+		return payload, nil
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(payload) < nonceSize {
+		return nil, errInvalidValue
+	}
+	plain, err := c.aead.Open(nil, payload[:nonceSize], payload[nonceSize:], []byte(name))
+	if err != nil {
+		return nil, errInvalidValue
+	}
+	return plain, nil
+}
+
+func (c *codec) mac(name string, b []byte) []byte {
+	h := hmac.New(sha256.New, c.hashKey)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// 使用第一组密钥编码
+func encodeMulti(codecs []*codec, name string, value []byte) (string, error) {
+	if len(codecs) == 0 {
+		return "", errInvalidKey
+	}
+	return codecs[0].encode(name, value, time.Now())
+}
+
+// 依次尝试所有密钥解码，支持密钥轮换
+func decodeMulti(codecs []*codec, name string, value string, maxAge time.Duration) ([]byte, error) {
+	err := errInvalidValue
+	for _, c := range codecs {
+		var b []byte
+		if b, err = c.decode(name, value, maxAge, time.Now()); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,257 @@
+package sessions
+
+import (
+	"bytes"
+	"encoding/gob"
+	"gee-demo/gee"
+	"log"
+	"net/http"
+	"time"
+)
+
+/* ---------------------------------- 会话 ---------------------------------- */
+/**
+ * 会话中间件在第一次使用时通过 Store 加载会话，会话被修改后，在响应头写出之前自动保存
+ *  CookieStore：会话数据签名并加密后保存在 cookie 中
+ *  MemoryStore：会话数据保存在服务端内存，cookie 中只保存签名后的会话ID
+ * 会话值使用 encoding/gob 序列化，自定义类型需要先调用 gob.Register 注册
+ */
+
+// 会话在 ctx 键值存储中的key
+const sessionKey = "gee.session"
+
+// 默认的闪存消息分类
+const flashKey = "_flash"
+
+type Store interface {
+	// 从请求中加载会话，不存在或无法解析时返回新的会话
+	Get(ctx *gee.Context, name string) (*Session, error)
+	// 保存会话并写入 cookie，Options.MaxAge < 0 时删除会话
+	Save(ctx *gee.Context, session *Session) error
+}
+
+// cookie 属性
+type Options struct {
+	Path   string
+	Domain string
+	// 有效期(秒)，0 表示浏览器会话 cookie，< 0 表示删除
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+func defaultOptions() *Options {
+	return &Options{
+		Path:     "/",
+		MaxAge:   7 * 24 * 3600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+type Session struct {
+	// 服务端存储的会话ID，cookie 存储时为空
+	ID      string
+	Values  map[string]interface{}
+	Options *Options
+	// 是否为新创建的会话
+	IsNew bool
+
+	name     string
+	store    Store
+	modified bool
+}
+
+// 实例化一个空会话
+func NewSession(store Store, name string) *Session {
+	return &Session{
+		Values:  make(map[string]interface{}),
+		Options: defaultOptions(),
+		IsNew:   true,
+		name:    name,
+		store:   store,
+	}
+}
+
+func (session *Session) Name() string {
+	return session.name
+}
+
+func (session *Session) Get(key string) interface{} {
+	return session.Values[key]
+}
+
+func (session *Session) Set(key string, value interface{}) {
+	session.Values[key] = value
+	session.modified = true
+}
+
+func (session *Session) Delete(key string) {
+	delete(session.Values, key)
+	session.modified = true
+}
+
+// 清空会话中的所有值
+func (session *Session) Clear() {
+	session.Values = make(map[string]interface{})
+	session.modified = true
+}
+
+// 销毁会话，保存时删除存储并让 cookie 过期
+func (session *Session) Invalidate() {
+	session.Clear()
+	session.Options.MaxAge = -1
+}
+
+// 添加一条闪存消息，只能被读取一次，category 默认为 _flash
+func (session *Session) AddFlash(value interface{}, category ...string) {
+	key := flashKey
+	if len(category) > 0 {
+		key = category[0]
+	}
+
+	flashes, _ := session.Values[key].([]interface{})
+	session.Values[key] = append(flashes, value)
+	session.modified = true
+}
+
+// 读取并删除闪存消息
+func (session *Session) Flashes(category ...string) []interface{} {
+	key := flashKey
+	if len(category) > 0 {
+		key = category[0]
+	}
+
+	flashes, ok := session.Values[key].([]interface{})
+	if ok {
+		delete(session.Values, key)
+		session.modified = true
+	}
+	return flashes
+}
+
+// 立即保存会话
+func (session *Session) Save(ctx *gee.Context) error {
+	if err := session.store.Save(ctx, session); err != nil {
+		return err
+	}
+	session.modified = false
+	return nil
+}
+
+func init() {
+	// 闪存消息以 []interface{} 保存
+	gob.Register([]interface{}{})
+}
+
+func encodeValues(values map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeValues(b []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (options *Options) cookie(name string, value string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     options.Path,
+		Domain:   options.Domain,
+		MaxAge:   options.MaxAge,
+		Secure:   options.Secure,
+		HttpOnly: options.HttpOnly,
+		SameSite: options.SameSite,
+	}
+	if options.MaxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(options.MaxAge) * time.Second)
+	} else if options.MaxAge < 0 {
+		cookie.Expires = time.Unix(1, 0)
+	}
+	return cookie
+}
+
+/* ---------------------------------- 中间件 ---------------------------------- */
+
+// 会话中间件，name 为 cookie 名称
+func Sessions(name string, store Store) gee.HandlerFunc {
+	return func(ctx *gee.Context) {
+		holder := &holder{ctx: ctx, name: name, store: store}
+		ctx.Set(sessionKey, holder)
+
+		// 在响应头写出之前保存被修改的会话
+		writer := &sessionWriter{ResponseWriter: ctx.Res, holder: holder}
+		ctx.Res = writer
+		defer func() {
+			ctx.Res = writer.ResponseWriter
+		}()
+
+		ctx.Next()
+
+		holder.save()
+	}
+}
+
+// 获取当前请求的会话
+func Default(ctx *gee.Context) *Session {
+	return ctx.MustGet(sessionKey).(*holder).session()
+}
+
+// 延迟加载会话
+type holder struct {
+	ctx   *gee.Context
+	name  string
+	store Store
+	s     *Session
+}
+
+func (h *holder) session() *Session {
+	if h.s == nil {
+		s, err := h.store.Get(h.ctx, h.name)
+		if err != nil {
+			log.Printf("[M - Sessions] load session %s: %v", h.name, err)
+		}
+		h.s = s
+	}
+	return h.s
+}
+
+func (h *holder) save() {
+	if h.s == nil || !h.s.modified {
+		return
+	}
+	if err := h.s.Save(h.ctx); err != nil {
+		log.Printf("[M - Sessions] save session %s: %v", h.name, err)
+	}
+}
+
+type sessionWriter struct {
+	http.ResponseWriter
+	holder *holder
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.holder.save()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.holder.save()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *sessionWriter) Flush() {
+	w.holder.save()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,139 @@
+package sessions
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	hashKey    = []byte("very-secret-hash-key")
+	encryptKey = []byte("0123456789abcdef0123456789abcdef")
+)
+
+func newEngine(store Store) *gee.Engine {
+	engine := gee.New()
+	engine.Use(Sessions("gee_session", store))
+	engine.Get("/set", func(ctx *gee.Context) {
+		session := Default(ctx)
+		session.Set("user", "tom")
+		session.AddFlash("welcome")
+		ctx.String(http.StatusOK, "ok")
+	})
+	engine.Get("/get", func(ctx *gee.Context) {
+		session := Default(ctx)
+		user, _ := session.Get("user").(string)
+		flashes := session.Flashes()
+		ctx.String(http.StatusOK, "%s %v", user, flashes)
+	})
+	engine.Get("/logout", func(ctx *gee.Context) {
+		Default(ctx).Invalidate()
+		ctx.String(http.StatusOK, "bye")
+	})
+	return engine
+}
+
+// 发起请求并携带cookie，返回响应中设置的cookie
+func request(engine *gee.Engine, path string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	for _, c := range res.Result().Cookies() {
+		if c.Name == "gee_session" {
+			return res, c
+		}
+	}
+	return res, nil
+}
+
+func testStore(t *testing.T, store Store) {
+	engine := newEngine(store)
+
+	_, cookie := request(engine, "/set", nil)
+	if cookie == nil || cookie.SameSite != http.SameSiteLaxMode || !cookie.HttpOnly {
+		t.Fatalf("session cookie should be set, got %v", cookie)
+	}
+
+	res, flashed := request(engine, "/get", cookie)
+	if res.Body.String() != "tom [welcome]" {
+		t.Fatalf("session value mismatch, body %q", res.Body.String())
+	}
+	// 闪存消息只能读取一次
+	if flashed == nil {
+		t.Fatalf("reading flashes should save session")
+	}
+	if res, _ := request(engine, "/get", flashed); res.Body.String() != "tom []" {
+		t.Fatalf("flash should be consumed, body %q", res.Body.String())
+	}
+
+	// 篡改的cookie被忽略
+	tampered := *cookie
+	tampered.Value = strings.ToUpper(tampered.Value[:10]) + tampered.Value[10:]
+	if res, _ := request(engine, "/get", &tampered); res.Body.String() != " []" {
+		t.Fatalf("tampered cookie should be rejected, body %q", res.Body.String())
+	}
+
+	_, expired := request(engine, "/logout", flashed)
+	if expired == nil || expired.MaxAge >= 0 {
+		t.Fatalf("logout should expire cookie, got %v", expired)
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	store, err := NewCookieStore(hashKey, encryptKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store, err := NewMemoryStore(hashKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+
+	if store.Len() != 0 {
+		t.Fatalf("invalidated session should be deleted, len %d", store.Len())
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldStore, _ := NewCookieStore(hashKey, encryptKey)
+	_, cookie := request(newEngine(oldStore), "/set", nil)
+
+	newKey := []byte("fedcba9876543210fedcba9876543210")
+	rotated, _ := NewCookieStore([]byte("new-hash-key"), newKey, hashKey, encryptKey)
+	if res, _ := request(newEngine(rotated), "/get", cookie); res.Body.String() != "tom [welcome]" {
+		t.Fatalf("cookie encoded by old key should be decoded, body %q", res.Body.String())
+	}
+
+	onlyNew, _ := NewCookieStore([]byte("new-hash-key"), newKey)
+	if res, _ := request(newEngine(onlyNew), "/get", cookie); res.Body.String() != " []" {
+		t.Fatalf("cookie encoded by removed key should be rejected, body %q", res.Body.String())
+	}
+}
+
+func TestCodecMaxAge(t *testing.T) {
+	codecs, _ := newCodecs(hashKey, encryptKey)
+	now := time.Now()
+	value, _ := codecs[0].encode("name", []byte("value"), now.Add(-2*time.Hour))
+
+	if _, err := codecs[0].decode("name", value, time.Hour, now); err != errExpiredValue {
+		t.Fatalf("expect expired error, got %v", err)
+	}
+	if _, err := codecs[0].decode("other", value, 0, now); err != errInvalidValue {
+		t.Fatalf("cookie name should be bound, got %v", err)
+	}
+	if b, err := codecs[0].decode("name", value, 0, now); err != nil || string(b) != "value" {
+		t.Fatalf("decode failed, %q %v", b, err)
+	}
+}
@@ -0,0 +1,226 @@
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"gee-demo/gee"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- Cookie 存储 ---------------------------------- */
+
+type CookieStore struct {
+	codecs []*codec
+	// 新会话的默认 cookie 属性
+	Options *Options
+}
+
+// keyPairs 为 hashKey, encryptKey 成对传入，第一组用于编码，其余用于解码旧的 cookie，实现密钥轮换
+//
+//	sessions.NewCookieStore(newHashKey, newEncryptKey, oldHashKey, oldEncryptKey)
+func NewCookieStore(keyPairs ...[]byte) (*CookieStore, error) {
+	codecs, err := newCodecs(keyPairs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(codecs) == 0 {
+		return nil, errInvalidKey
+	}
+	return &CookieStore{codecs: codecs, Options: defaultOptions()}, nil
+}
+
+func (store *CookieStore) Get(ctx *gee.Context, name string) (*Session, error) {
+	session := newSessionWithOptions(store, name, store.Options)
+
+	value, err := ctx.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	b, err := decodeMulti(store.codecs, name, value, maxAge(session.Options))
+	if err != nil {
+		return session, err
+	}
+	values, err := decodeValues(b)
+	if err != nil {
+		return session, err
+	}
+
+	session.Values = values
+	session.IsNew = false
+	return session, nil
+}
+
+func (store *CookieStore) Save(ctx *gee.Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		ctx.SetCookie(session.Options.cookie(session.name, ""))
+		return nil
+	}
+
+	b, err := encodeValues(session.Values)
+	if err != nil {
+		return err
+	}
+	value, err := encodeMulti(store.codecs, session.name, b)
+	if err != nil {
+		return err
+	}
+	// 浏览器限制单个 cookie 约 4KB
+	if len(value) > 4096 {
+		return errors.New("sessions: the value is too long for cookie store")
+	}
+
+	ctx.SetCookie(session.Options.cookie(session.name, value))
+	return nil
+}
+
+/* ---------------------------------- 内存存储 ---------------------------------- */
+
+// 清理过期会话的间隔
+const sweepInterval = time.Minute
+
+type MemoryStore struct {
+	codecs  []*codec
+	Options *Options
+
+	mutex     sync.Mutex
+	sessions  map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	// 序列化后的会话值，避免不同请求共享同一个 map
+	data     []byte
+	expireAt time.Time
+}
+
+// keyPairs 用于对 cookie 中的会话ID签名，用法同 NewCookieStore
+func NewMemoryStore(keyPairs ...[]byte) (*MemoryStore, error) {
+	codecs, err := newCodecs(keyPairs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(codecs) == 0 {
+		return nil, errInvalidKey
+	}
+	return &MemoryStore{
+		codecs:   codecs,
+		Options:  defaultOptions(),
+		sessions: make(map[string]*memoryEntry),
+	}, nil
+}
+
+func (store *MemoryStore) Get(ctx *gee.Context, name string) (*Session, error) {
+	session := newSessionWithOptions(store, name, store.Options)
+
+	value, err := ctx.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	id, err := decodeMulti(store.codecs, name, value, maxAge(session.Options))
+	if err != nil {
+		return session, err
+	}
+
+	store.mutex.Lock()
+	entry, ok := store.sessions[string(id)]
+	if ok && !time.Now().Before(entry.expireAt) {
+		delete(store.sessions, string(id))
+		ok = false
+	}
+	store.mutex.Unlock()
+
+	// 会话已过期，使用新的会话
+	if !ok {
+		return session, nil
+	}
+	values, err := decodeValues(entry.data)
+	if err != nil {
+		return session, err
+	}
+
+	session.ID = string(id)
+	session.Values = values
+	session.IsNew = false
+	return session, nil
+}
+
+func (store *MemoryStore) Save(ctx *gee.Context, session *Session) error {
+	if session.Options.MaxAge < 0 {
+		store.mutex.Lock()
+		delete(store.sessions, session.ID)
+		store.mutex.Unlock()
+
+		ctx.SetCookie(session.Options.cookie(session.name, ""))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = newSessionID()
+	}
+	data, err := encodeValues(session.Values)
+	if err != nil {
+		return err
+	}
+
+	// 浏览器会话 cookie 在服务端最多保留一天
+	ttl := 24 * time.Hour
+	if session.Options.MaxAge > 0 {
+		ttl = time.Duration(session.Options.MaxAge) * time.Second
+	}
+
+	now := time.Now()
+	store.mutex.Lock()
+	if now.Sub(store.lastSweep) > sweepInterval {
+		store.sweep(now)
+	}
+	store.sessions[session.ID] = &memoryEntry{data: data, expireAt: now.Add(ttl)}
+	store.mutex.Unlock()
+
+	value, err := encodeMulti(store.codecs, session.name, []byte(session.ID))
+	if err != nil {
+		return err
+	}
+	ctx.SetCookie(session.Options.cookie(session.name, value))
+	return nil
+}
+
+// 当前保存的会话数量
+func (store *MemoryStore) Len() int {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return len(store.sessions)
+}
+
+// 清理过期会话
+func (store *MemoryStore) sweep(now time.Time) {
+	store.lastSweep = now
+	for id, entry := range store.sessions {
+		if !now.Before(entry.expireAt) {
+			delete(store.sessions, id)
+		}
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// 复制 store 的默认属性，避免不同会话互相影响
+func newSessionWithOptions(store Store, name string, options *Options) *Session {
+	session := NewSession(store, name)
+	opts := *options
+	session.Options = &opts
+	return session
+}
+
+func maxAge(options *Options) time.Duration {
+	return time.Duration(options.MaxAge) * time.Second
+}
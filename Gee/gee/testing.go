@@ -0,0 +1,25 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+/* ---------------------------------- 测试辅助 ---------------------------------- */
+
+// 处理一个请求并返回记录的响应，用于测试
+func (engine *Engine) Test(req *http.Request) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+	return res
+}
+
+// 实例化一个不经过路由的Context，用于单独测试某个 HandlerFunc
+// handlers 为调用 ctx.Next() 时依次执行的处理函数
+func (engine *Engine) NewContext(res http.ResponseWriter, req *http.Request, handlers ...HandlerFunc) *Context {
+	ctx := newContext(res, req)
+	ctx.engine = engine
+	ctx.Params = make(map[string]string)
+	ctx.middlewares = handlers
+	return ctx
+}
@@ -0,0 +1,156 @@
+package gee
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 超时控制 ---------------------------------- */
+/**
+ * 后续的中间件和路由处理在新的 goroutine 中执行，并给请求的 context 设置 deadline
+ * 处理期间的响应先写入缓冲区：
+ *  按时完成：将缓冲的响应头、状态码和响应体写回
+ *  超时：丢弃缓冲区，之后的写入返回 http.ErrHandlerTimeout，并返回超时响应
+ * 超时后 handler 中发生的 panic 无法再交给 Recovery，直接记录日志
+ * 由于响应被缓冲，流式响应的路由不应使用该中间件
+ */
+
+type TimeoutConfig struct {
+	// 超时时间，小于等于 0 时不限制
+	Timeout time.Duration
+	// 超时后的响应，默认返回 503
+	Handler HandlerFunc
+}
+
+// 超时控制，超时后返回 503
+func Timeout(timeout time.Duration) HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+// 根据配置实例化一个超时中间件
+func TimeoutWithConfig(config TimeoutConfig) HandlerFunc {
+	if config.Handler == nil {
+		config.Handler = func(ctx *Context) {
+			ctx.FatalT(http.StatusServiceUnavailable, "gee.service_unavailable")
+		}
+	}
+
+	if config.Timeout <= 0 {
+		return func(ctx *Context) {
+			ctx.Next()
+		}
+	}
+
+	return func(ctx *Context) {
+		reqCtx, cancel := context.WithTimeout(ctx.Req.Context(), config.Timeout)
+		defer cancel()
+		ctx.Req = ctx.Req.WithContext(reqCtx)
+
+		// 处理请求的goroutine只使用forked，避免与当前goroutine竞争ctx
+		writer := &timeoutWriter{header: make(http.Header)}
+		forked := ctx.fork(writer)
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					writer.mutex.Lock()
+					timedOut := writer.timedOut
+					if !timedOut {
+						panicChan <- p
+					}
+					writer.mutex.Unlock()
+					// 已经超时，没有人再接收panic
+					if timedOut {
+						forked.reportPanic(p)
+					}
+				}
+			}()
+			forked.Next()
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			// 在当前goroutine重新panic，交给 Recovery 处理
+			panic(p)
+		case <-done:
+			writer.mutex.Lock()
+			defer writer.mutex.Unlock()
+
+			header := ctx.Res.Header()
+			for key, values := range writer.header {
+				header[key] = values
+			}
+			if writer.wroteHeader {
+				ctx.Status(writer.status)
+			}
+			ctx.StatusCode = forked.StatusCode
+			ctx.Errors = append(ctx.Errors, forked.Errors...)
+			ctx.Res.Write(writer.buf.Bytes())
+		case <-reqCtx.Done():
+			writer.mutex.Lock()
+			writer.timedOut = true
+			writer.mutex.Unlock()
+			// 超时的同时发生的panic
+			select {
+			case p := <-panicChan:
+				forked.reportPanic(p)
+			default:
+			}
+
+			// 客户端主动断开，无需响应
+			if reqCtx.Err() != context.DeadlineExceeded {
+				return
+			}
+			config.Handler(ctx)
+		}
+	}
+}
+
+// 缓冲响应的Writer，超时后丢弃所有写入
+type timeoutWriter struct {
+	mutex       sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.status = http.StatusOK
+		w.wroteHeader = true
+	}
+	return w.buf.Write(b)
+}
@@ -0,0 +1,144 @@
+package gee
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	engine := New()
+	engine.Use(Timeout(50 * time.Millisecond))
+	engine.Get("/fast", func(ctx *Context) {
+		ctx.SetHeader("X-Fast", "1")
+		ctx.String(http.StatusCreated, "fast")
+	})
+	engine.Get("/slow", func(ctx *Context) {
+		<-ctx.Req.Context().Done()
+		// 超时后的写入会被丢弃
+		ctx.String(http.StatusOK, "slow")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if res.Code != http.StatusCreated || res.Body.String() != "fast" || res.Header().Get("X-Fast") != "1" {
+		t.Fatalf("fast handler response should be kept, got %d %q", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow handler should time out, got %d", res.Code)
+	}
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	var status int
+	engine := New()
+	engine.Use(func(ctx *Context) {
+		ctx.Next()
+		status = ctx.StatusCode
+	})
+	engine.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout: 10 * time.Millisecond,
+		Handler: func(ctx *Context) {
+			ctx.Fatal(http.StatusGatewayTimeout, "Gateway Timeout")
+		},
+	}))
+	engine.Get("/slow", func(ctx *Context) {
+		time.Sleep(50 * time.Millisecond)
+		ctx.String(http.StatusOK, "slow")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if res.Code != http.StatusGatewayTimeout || status != http.StatusGatewayTimeout {
+		t.Fatalf("expect 504, got %d", res.Code)
+	}
+	// 等待超时的handler结束，确保其写入不会影响响应
+	time.Sleep(60 * time.Millisecond)
+	if res.Body.String() != "{\"message\":\"Gateway Timeout\"}\n" {
+		t.Fatalf("late write should be discarded, body %q", res.Body.String())
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	engine := New()
+	engine.Use(Recovery(), Timeout(time.Second))
+	engine.Get("/panic", func(ctx *Context) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("panic should be recovered, got %d", res.Code)
+	}
+}
+
+// 同步写入的日志
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestTimeoutLatePanic(t *testing.T) {
+	logs := &syncBuffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	engine := New()
+	engine.Use(Recovery(), Timeout(10*time.Millisecond))
+	engine.Get("/late", func(ctx *Context) {
+		<-ctx.Req.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		panic("late boom")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/late", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503, got %d", res.Code)
+	}
+
+	// 超时后的panic也要记录日志
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(logs.String(), "[E - Panic] [500] late boom") {
+		if time.Now().After(deadline) {
+			t.Fatalf("late panic should be logged, got %q", logs.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	engine := New()
+	engine.Use(Timeout(0))
+	engine.Get("/", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "ok" {
+		t.Fatalf("non-positive timeout should not limit requests, got %d", res.Code)
+	}
+}
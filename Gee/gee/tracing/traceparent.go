@@ -0,0 +1,169 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+/* --------------------------- W3C Trace Context 传播 --------------------------- */
+/**
+ * traceparent: {version}-{trace-id}-{parent-id}-{trace-flags}
+ *  例如 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+ * tracestate: 厂商自定义的键值对列表，原样向下游传递
+ *  例如 congo=t61rcWkgMzE,rojo=00f067aa0ba902b7
+ */
+
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+
+	// tracestate 最多32个键值对
+	maxTracestateMembers = 32
+	flagSampled          = 0x01
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// 跨进程传递的链路上下文
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+// 解析 traceparent 与 tracestate
+func Extract(header http.Header) (SpanContext, error) {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return SpanContext{}, err
+	}
+	sc.TraceState = parseTracestate(header.Values(TracestateHeader))
+	return sc, nil
+}
+
+// 写入 traceparent 与 tracestate
+func (sc SpanContext) Inject(header http.Header) {
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(TracestateHeader, sc.TraceState)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// 格式化为 traceparent
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	value = strings.TrimSpace(value)
+
+	// 00 版本长度固定为55，更高版本可以在末尾追加字段
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, errInvalidTraceparent
+	}
+	version, err := decodeHex(value[0:2])
+	if err != nil || version[0] == 0xff {
+		return sc, errInvalidTraceparent
+	}
+	if version[0] == 0 && len(value) != 55 {
+		return sc, errInvalidTraceparent
+	}
+	if len(value) > 55 && value[55] != '-' {
+		return sc, errInvalidTraceparent
+	}
+
+	traceID, err := decodeHex(value[3:35])
+	if err != nil {
+		return sc, errInvalidTraceparent
+	}
+	spanID, err := decodeHex(value[36:52])
+	if err != nil {
+		return sc, errInvalidTraceparent
+	}
+	flags, err := decodeHex(value[53:55])
+	if err != nil {
+		return sc, errInvalidTraceparent
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&flagSampled != 0
+
+	return sc, nil
+}
+
+// 规范中只允许小写十六进制
+func decodeHex(s string) ([]byte, error) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return nil, errInvalidTraceparent
+		}
+	}
+	return hex.DecodeString(s)
+}
+
+// 合并多个 tracestate 请求头，丢弃不合法的成员
+func parseTracestate(values []string) string {
+	members := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		for _, member := range strings.Split(value, ",") {
+			member = strings.TrimSpace(member)
+			if member == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(member, "=")
+			if !ok || key == "" || val == "" || seen[key] {
+				return ""
+			}
+			seen[key] = true
+			members = append(members, member)
+		}
+	}
+
+	if len(members) > maxTracestateMembers {
+		return ""
+	}
+	return strings.Join(members, ",")
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return
+}
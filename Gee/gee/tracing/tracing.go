@@ -0,0 +1,172 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"gee-demo/gee"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------- 链路追踪 ---------------------------------- */
+/**
+ * 每个请求创建一个 Span，名称为 "{method} {route pattern}"，如 GET /p/:lang/doc
+ *  请求携带合法的 traceparent 时，沿用其 trace-id，并以其 parent-id 作为父 Span
+ *  否则开启一条新的链路
+ * Span 结束后交给 Exporter 导出，未采样的 Span 不导出
+ * handler panic 时同样导出，并记录 500 状态码与错误信息
+ */
+
+// 当前 Span 在 ctx 键值存储中的key
+const SpanKey = "gee.span"
+
+type Span struct {
+	SpanContext
+	// 父Span，根Span为空
+	ParentSpanID SpanID
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+}
+
+// Span 导出器，可以对接 Jaeger、Zipkin、OTLP 等
+type Exporter interface {
+	Export(span *Span)
+}
+
+type Config struct {
+	Exporter Exporter
+	// 新链路是否采样，默认全部采样；上游已有链路时沿用上游的采样标记
+	Sampler func(ctx *gee.Context) bool
+}
+
+// 根据配置实例化一个链路追踪中间件
+func New(config Config) gee.HandlerFunc {
+	if config.Exporter == nil {
+		panic("tracing: nil Exporter")
+	}
+
+	return func(ctx *gee.Context) {
+		span := &Span{
+			Start:      time.Now(),
+			Attributes: make(map[string]string),
+		}
+
+		if parent, err := Extract(ctx.Req.Header); err == nil {
+			span.TraceID = parent.TraceID
+			span.ParentSpanID = parent.SpanID
+			span.Sampled = parent.Sampled
+			span.TraceState = parent.TraceState
+		} else {
+			span.TraceID = newTraceID()
+			span.Sampled = config.Sampler == nil || config.Sampler(ctx)
+		}
+		span.SpanID = newSpanID()
+
+		// 向下游传递当前 Span
+		ctx.Set(SpanKey, span)
+		ctx.Req = ctx.Req.WithContext(context.WithValue(ctx.Req.Context(), spanContextKey{}, span))
+		span.SpanContext.Inject(ctx.Res.Header())
+
+		// 后续的 handler panic 时也要导出 Span，之后继续向上 panic 交给 Recovery
+		defer func() {
+			p := recover()
+			finish(ctx, span, p)
+			if span.Sampled {
+				config.Exporter.Export(span)
+			}
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		ctx.Next()
+	}
+}
+
+// 结束 Span 并记录请求的结果
+func finish(ctx *gee.Context, span *Span, p interface{}) {
+	span.End = time.Now()
+	span.Name = spanName(ctx)
+	span.Attributes["http.method"] = ctx.Method
+	span.Attributes["http.route"] = ctx.Pattern
+	span.Attributes["http.target"] = ctx.Req.URL.RequestURI()
+	span.Attributes["http.status_code"] = strconv.Itoa(ctx.StatusCode)
+	if id := ctx.RequestID(); id != "" {
+		span.Attributes["http.request_id"] = id
+	}
+
+	if p != nil {
+		// 响应还未写入，最终由 Recovery 返回 500
+		span.Attributes["http.status_code"] = strconv.Itoa(http.StatusInternalServerError)
+		span.Attributes["error"] = fmt.Sprintf("panic: %v", p)
+	} else if len(ctx.Errors) > 0 {
+		span.Attributes["error"] = ctx.Errors[len(ctx.Errors)-1].Error()
+	}
+}
+
+// 使用路由规则而不是实际路径命名，避免 Span 名称过多
+func spanName(ctx *gee.Context) string {
+	if ctx.Pattern == "" {
+		return ctx.Method
+	}
+	return ctx.Method + " " + ctx.Pattern
+}
+
+type spanContextKey struct{}
+
+// 获取请求 context 中的当前 Span
+func SpanFromContext(c context.Context) (*Span, bool) {
+	span, ok := c.Value(spanContextKey{}).(*Span)
+	return span, ok
+}
+
+// 将当前 Span 注入到下游请求，例如
+//
+//	req, _ := http.NewRequestWithContext(ctx.Req.Context(), "GET", url, nil)
+//	tracing.InjectRequest(req)
+func InjectRequest(req *http.Request) {
+	if span, ok := SpanFromContext(req.Context()); ok {
+		span.SpanContext.Inject(req.Header)
+	}
+}
+
+/* --------------------------------- 内存导出器 -------------------------------- */
+
+// 将 Span 保存在内存中，用于测试
+type MemoryExporter struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (exporter *MemoryExporter) Export(span *Span) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	exporter.spans = append(exporter.spans, span)
+}
+
+// 已导出的 Span
+func (exporter *MemoryExporter) Spans() []*Span {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	spans := make([]*Span, len(exporter.spans))
+	copy(spans, exporter.spans)
+	return spans
+}
+
+// 清空已导出的 Span
+func (exporter *MemoryExporter) Reset() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	exporter.spans = nil
+}
@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"gee-demo/gee"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent(parent)
+	if err != nil || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Fatalf("failed to parse traceparent, %+v %v", sc, err)
+	}
+	if sc.Traceparent() != parent {
+		t.Fatalf("format traceparent got %s", sc.Traceparent())
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	}
+	for _, value := range invalid {
+		if _, err := ParseTraceparent(value); err == nil {
+			t.Fatalf("traceparent %q should be invalid", value)
+		}
+	}
+
+	// 更高版本允许追加字段
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Fatalf("future version should be accepted: %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := NewMemoryExporter()
+	engine := gee.New()
+	engine.Use(gee.RequestID(), New(Config{Exporter: exporter}))
+
+	var downstream string
+	engine.Get("/users/:id", func(ctx *gee.Context) {
+		req, _ := http.NewRequestWithContext(ctx.Req.Context(), http.MethodGet, "http://downstream", nil)
+		InjectRequest(req)
+		downstream = req.Header.Get(TraceparentHeader)
+		ctx.String(http.StatusOK, ctx.Param("id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(TraceparentHeader, parent)
+	req.Header.Set(TracestateHeader, "congo=t61rcWkgMzE")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expect 1 span, but got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "GET /users/:id" || span.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("span mismatch: %+v", span)
+	}
+	if span.Attributes["http.status_code"] != "200" || span.Attributes["http.request_id"] != res.Header().Get(gee.RequestIDHeader) {
+		t.Fatalf("span attributes mismatch: %v", span.Attributes)
+	}
+	if downstream != span.Traceparent() || res.Header().Get(TraceparentHeader) != span.Traceparent() {
+		t.Fatalf("traceparent should be propagated, got %q", downstream)
+	}
+	if res.Header().Get(TracestateHeader) != "congo=t61rcWkgMzE" {
+		t.Fatalf("tracestate should be propagated")
+	}
+
+	// 未采样的链路不导出
+	exporter.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(TraceparentHeader, parent[:len(parent)-2]+"00")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if len(exporter.Spans()) != 0 {
+		t.Fatalf("unsampled span should not be exported")
+	}
+}
+
+func TestPanic(t *testing.T) {
+	exporter := NewMemoryExporter()
+	engine := gee.New()
+	engine.Use(gee.Recovery(), New(Config{Exporter: exporter}))
+	engine.Get("/panic", func(ctx *gee.Context) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("panic should be recovered, got %d", res.Code)
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("span should be exported on panic, got %d", len(spans))
+	}
+	if attributes := spans[0].Attributes; attributes["http.status_code"] != "500" || attributes["error"] != "panic: boom" {
+		t.Fatalf("panic should be recorded on the span, got %v", attributes)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	exporter := NewMemoryExporter()
+	engine := gee.New()
+	engine.Use(gee.RequestID(), New(Config{Exporter: exporter}))
+	engine.Get("/", func(ctx *gee.Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(gee.RequestIDHeader, "req-123")
+	res := httptest.NewRecorder()
+	engine.ServeHTTP(res, req)
+
+	spans := exporter.Spans()
+	if len(spans) != 1 || spans[0].Attributes["http.request_id"] != "req-123" {
+		t.Fatalf("request id should be carried onto the span, got %v", spans)
+	}
+	if res.Header().Get(gee.RequestIDHeader) != "req-123" {
+		t.Fatalf("request id should be echoed, got %q", res.Header().Get(gee.RequestIDHeader))
+	}
+}
@@ -0,0 +1,182 @@
+package gee
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ---------------------------------- API 版本 ---------------------------------- */
+/**
+ * 每个版本对应一个前缀为 Prefix/版本 的 RouterGroup，版本按以下顺序确定：
+ *  1. 路径前缀：/api/v2/users
+ *  2. Accept 媒体类型：application/vnd.{Vendor}.v2+json
+ *  3. 自定义请求头：X-API-Version: v2
+ *  4. 默认版本
+ * 通过请求头协商时，在路由匹配之前将路径重写为 /api/v2/users
+ * 已废弃的版本在响应中附带 Deprecation(RFC 9745) 与 Sunset(RFC 8594) 响应头
+ */
+
+// 当前请求的 API 版本在 ctx 中的 key
+const VersionKey = "gee.apiVersion"
+
+type VersionConfig struct {
+	// 版本路由的公共前缀，如 /api，不能为空
+	Prefix string
+	// Accept 媒体类型中的厂商名，如 application/vnd.gee.v2+json 中的 gee，为空时不使用 Accept
+	Vendor string
+	// 指定版本的请求头，如 X-API-Version，为空时不使用
+	Header string
+	// 没有指定版本时使用的版本
+	Default string
+}
+
+// 版本废弃信息
+type Deprecation struct {
+	// 废弃的时间，为零值时不设置 Deprecation
+	At time.Time
+	// 停止服务的时间，为零值时不设置 Sunset
+	Sunset time.Time
+	// 迁移说明的链接
+	Link string
+}
+
+type Versions struct {
+	engine       *Engine
+	config       VersionConfig
+	groups       map[string]*RouterGroup
+	deprecations map[string]Deprecation
+}
+
+// 根据 Accept、请求头或路径前缀将请求路由到不同版本的 RouterGroup
+//
+//	api := engine.Versioned(gee.VersionConfig{Prefix: "/api", Vendor: "gee", Default: "v1"})
+//	api.Version("v1").Get("/users", listUsersV1)
+func (engine *Engine) Versioned(config VersionConfig) *Versions {
+	config.Prefix = strings.TrimSuffix(config.Prefix, "/")
+	// 没有前缀时所有请求都会被重写到版本路径下
+	if config.Prefix == "" {
+		panic("gee: versioned routes require a prefix")
+	}
+
+	versions := &Versions{
+		engine:       engine,
+		config:       config,
+		groups:       make(map[string]*RouterGroup),
+		deprecations: make(map[string]Deprecation),
+	}
+	engine.Pre(versions.negotiate)
+
+	return versions
+}
+
+// 获取某个版本的 RouterGroup，不存在时创建
+func (versions *Versions) Version(name string) *RouterGroup {
+	if group, ok := versions.groups[name]; ok {
+		return group
+	}
+
+	group := versions.engine.Group(versions.config.Prefix + "/" + name)
+	versions.groups[name] = group
+	return group
+}
+
+// 标记某个版本已废弃
+func (versions *Versions) Deprecate(name string, deprecation Deprecation) {
+	versions.deprecations[name] = deprecation
+}
+
+// 当前请求的 API 版本
+func (ctx *Context) APIVersion() string {
+	return ctx.GetString(VersionKey)
+}
+
+func (versions *Versions) negotiate(ctx *Context) {
+	config := versions.config
+	path := ctx.Req.URL.Path
+
+	rest, ok := strings.CutPrefix(path, config.Prefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		ctx.Next()
+		return
+	}
+
+	// 路径中已经指定版本
+	segment, _, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+	if _, ok := versions.groups[segment]; ok {
+		versions.use(ctx, segment)
+		ctx.Next()
+		return
+	}
+
+	// 响应随协商使用的请求头变化
+	if config.Vendor != "" {
+		ctx.Res.Header().Add("Vary", "Accept")
+	}
+	if config.Header != "" {
+		ctx.Res.Header().Add("Vary", config.Header)
+	}
+
+	version := versions.requested(ctx.Req)
+	if version == "" {
+		version = config.Default
+	}
+	if version == "" {
+		ctx.Next()
+		return
+	}
+	if _, ok := versions.groups[version]; !ok {
+		ctx.FatalT(http.StatusNotAcceptable, "gee.unsupported_version", H{"version": version})
+		return
+	}
+
+	ctx.Req.URL.Path = config.Prefix + "/" + version + rest
+	ctx.Req.URL.RawPath = ""
+	versions.use(ctx, version)
+	ctx.Next()
+}
+
+// 从 Accept 或请求头中获取版本
+func (versions *Versions) requested(req *http.Request) string {
+	if vendor := versions.config.Vendor; vendor != "" {
+		prefix := "application/vnd." + vendor + "."
+		for _, value := range req.Header.Values("Accept") {
+			for _, mediaType := range strings.Split(value, ",") {
+				mediaType, _, _ = strings.Cut(strings.TrimSpace(mediaType), ";")
+				if rest, ok := strings.CutPrefix(strings.ToLower(mediaType), prefix); ok {
+					// v2+json -> v2
+					version, _, _ := strings.Cut(rest, "+")
+					return version
+				}
+			}
+		}
+	}
+
+	if header := versions.config.Header; header != "" {
+		return strings.TrimSpace(req.Header.Get(header))
+	}
+
+	return ""
+}
+
+// 记录版本并设置废弃相关的响应头
+func (versions *Versions) use(ctx *Context, version string) {
+	ctx.Set(VersionKey, version)
+
+	deprecation, ok := versions.deprecations[version]
+	if !ok {
+		return
+	}
+
+	header := ctx.Res.Header()
+	if !deprecation.At.IsZero() {
+		header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.At.Unix(), 10))
+	}
+	if !deprecation.Sunset.IsZero() {
+		header.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		header.Add("Link", "<"+deprecation.Link+`>; rel="deprecation"`)
+	}
+}
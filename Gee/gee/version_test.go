@@ -0,0 +1,98 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersioned(t *testing.T) {
+	engine := New()
+	api := engine.Versioned(VersionConfig{Prefix: "/api", Vendor: "gee", Header: "X-API-Version", Default: "v1"})
+	handler := func(ctx *Context) {
+		ctx.String(http.StatusOK, "%s %s", ctx.APIVersion(), ctx.Param("id"))
+	}
+	api.Version("v1").Get("/users/:id", handler)
+	api.Version("v2").Get("/users/:id", handler)
+	engine.Get("/other", func(ctx *Context) {
+		ctx.String(http.StatusOK, "other %s", ctx.APIVersion())
+	})
+
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	api.Deprecate("v1", Deprecation{At: time.Unix(1700000000, 0), Sunset: sunset, Link: "https://example.com/migrate"})
+
+	cases := []struct {
+		path    string
+		headers map[string]string
+		expect  string
+	}{
+		{"/api/users/1", nil, "v1 1"},
+		{"/api/v2/users/1", nil, "v2 1"},
+		{"/api/users/1", map[string]string{"Accept": "text/html, application/vnd.gee.v2+json;q=0.9"}, "v2 1"},
+		{"/api/users/1", map[string]string{"X-API-Version": "v2"}, "v2 1"},
+		// 路径中的版本优先
+		{"/api/v1/users/1", map[string]string{"X-API-Version": "v2"}, "v1 1"},
+		{"/other", map[string]string{"X-API-Version": "v2"}, "other "},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		for key, value := range c.headers {
+			req.Header.Set(key, value)
+		}
+		if res := engine.Test(req); res.Body.String() != c.expect {
+			t.Fatalf("%s %v: expect %q, but got %d %q", c.path, c.headers, c.expect, res.Code, res.Body.String())
+		}
+	}
+
+	res := engine.Test(httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	header := res.Header()
+	if header.Get("Deprecation") != "@1700000000" || header.Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" || header.Get("Link") != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Fatalf("deprecated version should have deprecation headers, got %v", header)
+	}
+	if vary := header.Values("Vary"); len(vary) != 2 || vary[0] != "Accept" || vary[1] != "X-API-Version" {
+		t.Fatalf("negotiated response should vary by accept and the version header, got %v", vary)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	req.Header.Set("X-API-Version", "v2")
+	if res := engine.Test(req); res.Header().Get("Deprecation") != "" {
+		t.Fatalf("current version should not be deprecated")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	req.Header.Set("X-API-Version", "v9")
+	if res := engine.Test(req); res.Code != http.StatusNotAcceptable {
+		t.Fatalf("unknown version should get 406, got %d", res.Code)
+	}
+}
+
+func TestVersionedConfig(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("versioned routes without prefix should panic")
+			}
+		}()
+		New().Versioned(VersionConfig{Prefix: "/", Default: "v1"})
+	}()
+
+	engine := New()
+	api := engine.Versioned(VersionConfig{Prefix: "/api", Default: "v1"})
+	api.Version("v1").Get("/users", func(ctx *Context) {
+		ctx.String(http.StatusOK, "v1")
+	})
+	engine.Get("/healthz", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	// 没有废弃时间时只设置 Sunset
+	api.Deprecate("v1", Deprecation{Sunset: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)})
+
+	if res := engine.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil)); res.Body.String() != "ok" {
+		t.Fatalf("routes outside the prefix should not be rewritten, got %d %q", res.Code, res.Body.String())
+	}
+	res := engine.Test(httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	if _, ok := res.Header()["Deprecation"]; ok || res.Header().Get("Sunset") == "" {
+		t.Fatalf("zero deprecation time should omit the deprecation header, got %v", res.Header())
+	}
+}
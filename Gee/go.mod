@@ -1,3 +1,11 @@
 module gee-demo
 
 go 1.20
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	golang.org/x/net v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/text v0.21.0 // indirect
@@ -1,15 +1,19 @@
 package geecache
 
+import "time"
+
 /* -------------------------------- 缓存值的抽象与封装 ------------------------------- */
 /**
  * 实现 Value 接口
  *
- * ByteView 只有一个数据成员，b []byte，b 将会存储真实的缓存值。
+ * ByteView 的 b []byte 存储真实的缓存值，e 记录过期时间。
  * 选择 byte 类型是为了能够支持任意的数据类型的存储
  */
 
 type ByteView struct {
 	b []byte
+	// 过期时间，零值表示不过期
+	e time.Time
 }
 
 // 返回长度
@@ -27,6 +31,16 @@ func (byteView ByteView) ByteSlice() []byte {
 	return cloneBytes(byteView.b)
 }
 
+// 返回过期时间，零值表示不过期
+func (byteView ByteView) Expire() time.Time {
+	return byteView.e
+}
+
+// 是否已经过期
+func (byteView ByteView) expired(now time.Time) bool {
+	return !byteView.e.IsZero() && !now.Before(byteView.e)
+}
+
 // 拷贝byte
 func cloneBytes(b []byte) []byte {
 	clone := make([]byte, len(b))
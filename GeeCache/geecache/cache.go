@@ -1,17 +1,24 @@
 package geecache
 
 import (
-	"geecache/geecache/lru"
+	"geecache/geecache/policy"
 	"sync"
+	"time"
 )
 
 /* -------------------------------- 对缓存添加并发控制 ------------------------------- */
+/**
+ * 淘汰策略由 newPolicy 创建，默认使用 LRU
+ * 过期的记录在 get 时跳过并删除(惰性删除)，也可以通过 sweep 定期清理
+ */
 
 type cache struct {
 	// 互斥锁
 	mutex sync.Mutex
-	// lru缓存
-	lru *lru.Cache
+	// 淘汰策略
+	policy policy.Policy
+	// 创建淘汰策略，为 nil 时使用 LRU
+	newPolicy policy.Factory
 	// 缓存内存大小
 	cacheBytes int64
 }
@@ -22,11 +29,14 @@ func (cache *cache) set(key string, value ByteView) {
 	defer cache.mutex.Unlock()
 
 	// 延迟初始化
-	if cache.lru == nil {
-		cache.lru = lru.New(cache.cacheBytes, nil)
+	if cache.policy == nil {
+		if cache.newPolicy == nil {
+			cache.newPolicy = LRU
+		}
+		cache.policy = cache.newPolicy(cache.cacheBytes)
 	}
 
-	cache.lru.Set(key, value)
+	cache.policy.Set(key, value)
 }
 
 // 查找
@@ -34,13 +44,54 @@ func (cache *cache) get(key string) (value ByteView, ok bool) {
 	cache.mutex.Lock()
 	defer cache.mutex.Unlock()
 
-	if cache.lru == nil {
+	if cache.policy == nil {
 		return
 	}
 
-	if value, ok := cache.lru.Get(key); ok {
-		return value.(ByteView), true
+	if v, ok := cache.policy.Get(key); ok {
+		value := v.(ByteView)
+		// 已过期，惰性删除
+		if value.expired(time.Now()) {
+			cache.policy.Remove(key)
+			return ByteView{}, false
+		}
+		return value, true
 	}
 
 	return
 }
+
+// 删除所有过期的记录，返回删除的数量
+func (cache *cache) sweep() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.policy == nil {
+		return 0
+	}
+
+	now := time.Now()
+	expired := make([]string, 0)
+	cache.policy.Range(func(key string, value policy.Value) bool {
+		if value.(ByteView).expired(now) {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	for _, key := range expired {
+		cache.policy.Remove(key)
+	}
+
+	return len(expired)
+}
+
+// 缓存记录数量
+func (cache *cache) len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.policy == nil {
+		return 0
+	}
+	return cache.policy.Len()
+}
@@ -5,7 +5,9 @@ import (
 	"geecache/geecache/proto/geecachepb"
 	"geecache/geecache/singleflight"
 	"log"
+	"math/rand"
 	"sync"
+	"time"
 )
 
 /* -------------------------- 负责与外部交互，控制缓存存储和获取的主流程 ------------------------- */
@@ -17,6 +19,7 @@ import (
  *                 |-----> 是否应当从远程节点获取 -----> 与远程节点交互 --> 返回缓存值 ⑵
  *                             |  否
  *                             |-----> 调用`回调函数`，获取值并添加到缓存 --> 返回缓存值 ⑶
+ * 检查缓存时先查找 mainCache，再查找保存远程节点热门数据的 hotCache
  */
 
 /* --------------------------------- 回调 Getter --------------------------------- */
@@ -41,6 +44,23 @@ func (fn GetterFunc) Get(key string) ([]byte, error) {
 	return fn(key)
 }
 
+// 带有效期的 Getter，ttl 为 0 时使用 Group 的默认 TTL
+type TTLGetter interface {
+	GetWithTTL(key string) ([]byte, time.Duration, error)
+}
+
+// 返回源数据及其有效期，同时实现了 Getter 与 TTLGetter
+type TTLGetterFunc func(key string) ([]byte, time.Duration, error)
+
+func (fn TTLGetterFunc) Get(key string) ([]byte, error) {
+	bytes, _, err := fn(key)
+	return bytes, err
+}
+
+func (fn TTLGetterFunc) GetWithTTL(key string) ([]byte, time.Duration, error) {
+	return fn(key)
+}
+
 /* ---------------------------------- Group --------------------------------- */
 /**
  * Group是一个缓存名称空间和相关数据加载分布
@@ -51,11 +71,22 @@ type Group struct {
 	name string
 	// 缓存未命中时获取源数据的回调(callback)
 	getter Getter
-	// 并发缓存
+	// 并发缓存，保存本节点负责的 key
 	mainCache cache
+	// 保存其他节点负责的热门 key，避免每次都请求远程节点
+	hotCache cache
+	// 远程节点的结果每 hotSample 个中随机保存一个到 hotCache，0 表示不保存
+	hotSample int
 	peers     PeerPicker
 	// singleflight用于确保同一个key只会发起一次请求
 	loader *singleflight.Group
+	// 默认的有效期，0 表示不过期
+	ttl time.Duration
+	// 定期清理过期记录的间隔，0 表示只在访问时惰性删除
+	sweepInterval time.Duration
+	// 关闭时停止定期清理
+	done chan struct{}
+	once sync.Once
 }
 
 var (
@@ -66,7 +97,7 @@ var (
 )
 
 // 实例化一个Group
-func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
+func NewGroup(name string, cacheBytes int64, getter Getter, options ...Option) *Group {
 	if getter == nil {
 		panic("nil Getter")
 	}
@@ -78,13 +109,39 @@ func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
 		name:      name,
 		getter:    getter,
 		mainCache: cache{cacheBytes: cacheBytes},
+		hotCache:  cache{cacheBytes: cacheBytes / defaultHotRatio},
+		hotSample: defaultHotSample,
 		loader:    &singleflight.Group{},
+		done:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(group)
+	}
+	// mainCache 不限制内存或太小时不使用 hotCache，否则 hotCache 会无限增长
+	if group.mainCache.cacheBytes == 0 || group.hotCache.cacheBytes == 0 {
+		group.hotSample = 0
+	}
+	if group.sweepInterval > 0 {
+		go group.sweepLoop()
 	}
 	groups[name] = group
 
 	return group
 }
 
+// 停止定期清理，并从 Group 集合中移除
+func (group *Group) Close() {
+	group.once.Do(func() {
+		close(group.done)
+
+		mutex.Lock()
+		if groups[group.name] == group {
+			delete(groups, group.name)
+		}
+		mutex.Unlock()
+	})
+}
+
 // 获取一个Group
 func GetGroup(name string) *Group {
 	mutex.RLock()
@@ -112,7 +169,11 @@ func (group *Group) Get(key string) (ByteView, error) {
 	// ⑴缓存
 	// 从 mainCache 中查找缓存，如果存在则返回缓存值
 	if value, ok := group.mainCache.get(key); ok {
-		fmt.Println("[GeeCache] hit")
+		log.Println("[GeeCache] hit")
+		return value, nil
+	}
+	if value, ok := group.hotCache.get(key); ok {
+		log.Println("[GeeCache] hot hit")
 		return value, nil
 	}
 
@@ -160,13 +221,31 @@ func (group *Group) getFromPeer(peer PeerGetter, key string) (ByteView, error) {
 		return ByteView{}, err
 	}
 
-	return ByteView{b: res.Value}, nil
+	// 与远程节点的缓存同时过期
+	value := ByteView{b: res.Value}
+	if res.Expire > 0 {
+		value.e = time.UnixMilli(res.Expire)
+	}
+
+	// 热门的 key 被访问的次数多，更容易被抽中
+	if group.hotSample > 0 && rand.Intn(group.hotSample) == 0 {
+		group.hotCache.set(key, value)
+	}
+
+	return value, nil
 }
 
 // getLocally 调用用户回调函数 g.getter.Get() 获取源数据
 func (group *Group) getLocally(key string) (ByteView, error) {
 	// 源数据
-	bytes, err := group.getter.Get(key)
+	var bytes []byte
+	var ttl time.Duration
+	var err error
+	if getter, ok := group.getter.(TTLGetter); ok {
+		bytes, ttl, err = getter.GetWithTTL(key)
+	} else {
+		bytes, err = group.getter.Get(key)
+	}
 
 	if err != nil {
 		return ByteView{}, err
@@ -174,6 +253,12 @@ func (group *Group) getLocally(key string) (ByteView, error) {
 
 	// ByteView包装，并缓存
 	value := ByteView{b: cloneBytes(bytes)}
+	if ttl == 0 {
+		ttl = group.ttl
+	}
+	if ttl > 0 {
+		value.e = time.Now().Add(ttl)
+	}
 	group.populateCache(key, value)
 
 	return value, nil
@@ -183,3 +268,19 @@ func (group *Group) getLocally(key string) (ByteView, error) {
 func (group *Group) populateCache(key string, value ByteView) {
 	group.mainCache.set(key, value)
 }
+
+// 定期清理过期的记录，直到调用 Close
+func (group *Group) sweepLoop() {
+	ticker := time.NewTicker(group.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			group.mainCache.sweep()
+			group.hotCache.sweep()
+		case <-group.done:
+			return
+		}
+	}
+}
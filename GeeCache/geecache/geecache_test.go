@@ -2,8 +2,13 @@ package geecache
 
 import (
 	"fmt"
+	"geecache/geecache/policy"
+	"geecache/geecache/proto/geecachepb"
 	"log"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 )
 
 var db = map[string]string{
@@ -48,3 +53,183 @@ func TestGet(t *testing.T) {
 		fmt.Printf("cached view: %v\n", view)
 	}
 }
+
+func TestTTL(t *testing.T) {
+	loadCounts := make(map[string]int)
+	group := NewGroup("ttl", 2<<10, TTLGetterFunc(
+		func(key string) ([]byte, time.Duration, error) {
+			loadCounts[key]++
+			if key == "short" {
+				return []byte(key), 20 * time.Millisecond, nil
+			}
+			// 使用 Group 的默认 TTL
+			return []byte(key), 0, nil
+		},
+	), WithTTL(time.Hour))
+
+	long, _ := group.Get("long")
+	if expire := time.Until(long.Expire()); expire < 59*time.Minute || expire > time.Hour {
+		t.Fatalf("default ttl should be used, expire in %v", expire)
+	}
+
+	group.Get("short")
+	if group.Get("short"); loadCounts["short"] != 1 {
+		t.Fatalf("short should be cached before it expires")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if group.Get("short"); loadCounts["short"] != 2 {
+		t.Fatalf("expired short should be loaded again")
+	}
+
+	// 不过期的 Getter
+	forever := NewGroup("forever", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	if view, _ := forever.Get("key"); !view.Expire().IsZero() {
+		t.Fatalf("value without ttl should never expire")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	group := NewGroup("sweep", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithTTL(10*time.Millisecond), WithSweepInterval(5*time.Millisecond))
+	t.Cleanup(group.Close)
+
+	group.Get("a")
+	group.Get("b")
+	if group.mainCache.len() != 2 {
+		t.Fatalf("values should be cached")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for group.mainCache.len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired values should be swept, %d left", group.mainCache.len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 关闭后不再清理
+	group.Close()
+	group.Close()
+	if GetGroup("sweep") != nil {
+		t.Fatalf("closed group should be removed")
+	}
+	group.Get("c")
+	time.Sleep(30 * time.Millisecond)
+	if group.mainCache.len() != 1 {
+		t.Fatalf("closed group should stop sweeping")
+	}
+}
+
+func TestPeerExpire(t *testing.T) {
+	NewGroup("remote", 2<<10, TTLGetterFunc(func(key string) ([]byte, time.Duration, error) {
+		return []byte(key), time.Minute, nil
+	}))
+	server := httptest.NewServer(NewHTTPPool("remote"))
+	defer server.Close()
+
+	local := &Group{name: "remote"}
+	view, err := local.getFromPeer(&httpGetter{baseUrl: server.URL + "/" + defaultBasePath}, "Tom")
+	if err != nil || view.String() != "Tom" {
+		t.Fatalf("failed to get from peer: %v", err)
+	}
+	if expire := time.Until(view.Expire()); expire < 59*time.Second || expire > time.Minute {
+		t.Fatalf("peer value should expire with the owner, expire in %v", expire)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	})
+	for _, factory := range []policy.Factory{LRU, LFU, TwoQ, TinyLFU} {
+		group := NewGroup("policy", 2<<10, getter, WithPolicy(factory))
+		if view, err := group.Get("Tom"); err != nil || view.String() != "Tom" {
+			t.Fatalf("failed to get Tom: %v", err)
+		}
+		if _, ok := group.mainCache.get("Tom"); !ok {
+			t.Fatalf("Tom should be cached")
+		}
+		if expect, got := reflect.TypeOf(factory(0)), reflect.TypeOf(group.mainCache.policy); expect != got {
+			t.Fatalf("expect policy %v, but got %v", expect, got)
+		}
+	}
+}
+
+type fakePeer struct {
+	calls  int
+	expire time.Time
+}
+
+func (peer *fakePeer) PickPeer(key string) (PeerGetter, bool) {
+	return peer, true
+}
+
+func (peer *fakePeer) Get(in *geecachepb.Request, out *geecachepb.Response) error {
+	peer.calls++
+	out.Value = []byte("remote " + in.GetKey())
+	if !peer.expire.IsZero() {
+		out.Expire = peer.expire.UnixMilli()
+	}
+	return nil
+}
+
+func TestHotCache(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s is owned by peer", key)
+	})
+
+	group := NewGroup("hot", 2<<10, getter)
+	if group.hotCache.cacheBytes != 2<<10/8 || group.hotSample != 10 {
+		t.Fatalf("hot cache should default to 1/8 of main cache")
+	}
+
+	// 每个远程结果都保存
+	group = NewGroup("hot", 2<<10, getter, WithHotCache(0.25, 1))
+	peer := &fakePeer{}
+	group.RegisterPeers(peer)
+	for i := 0; i < 3; i++ {
+		if view, err := group.Get("Tom"); err != nil || view.String() != "remote Tom" {
+			t.Fatalf("failed to get Tom from peer: %v", err)
+		}
+	}
+	if peer.calls != 1 || group.mainCache.len() != 0 || group.hotCache.len() != 1 {
+		t.Fatalf("remote value should be kept in hot cache only, peer calls %d", peer.calls)
+	}
+
+	// 与远程节点同时过期
+	peer.expire = time.Now().Add(20 * time.Millisecond)
+	group.Get("Jack")
+	time.Sleep(30 * time.Millisecond)
+	group.Get("Jack")
+	if peer.calls != 3 {
+		t.Fatalf("expired hot value should be fetched again, peer calls %d", peer.calls)
+	}
+
+	// 超出预算时按自己的策略淘汰
+	peer.expire = time.Time{}
+	for i := 0; i < 100; i++ {
+		group.Get(fmt.Sprintf("key%02d", i))
+	}
+	if bytes := group.hotCache.len() * len("key00remote key00"); bytes > 2<<10/4 {
+		t.Fatalf("hot cache should be bounded, got %d bytes", bytes)
+	}
+
+	// 不使用 hotCache
+	group = NewGroup("hot", 2<<10, getter, WithHotCache(0, 0))
+	peer = &fakePeer{}
+	group.RegisterPeers(peer)
+	group.Get("Tom")
+	group.Get("Tom")
+	if peer.calls != 2 || group.hotCache.len() != 0 {
+		t.Fatalf("hot cache should be disabled, peer calls %d", peer.calls)
+	}
+
+	// mainCache 不限制内存时，hotCache 也不使用
+	group = NewGroup("hot", 0, getter, WithHotCache(0.25, 1))
+	if group.hotSample != 0 {
+		t.Fatalf("hot cache should be disabled without a memory limit")
+	}
+}
@@ -78,8 +78,12 @@ func (pool *HTTPPool) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// protobuf Marshal
-	body, err := proto.Marshal(&geecachepb.Response{Value: bytes.ByteSlice()})
+	// protobuf Marshal，同时返回过期时间
+	response := &geecachepb.Response{Value: bytes.ByteSlice()}
+	if expire := bytes.Expire(); !expire.IsZero() {
+		response.Expire = expire.UnixMilli()
+	}
+	body, err := proto.Marshal(response)
 	if err != nil {
 		http.Error(res, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,150 @@
+package lfu
+
+import (
+	"container/list"
+	"geecache/geecache/policy"
+)
+
+/* --------------------------------- LFU 算法 --------------------------------- */
+/**
+ * 按访问次数从小到大维护一个频率链表，每个频率节点下挂着该频率的记录（按最近访问排序）：
+ *  freq=1: [k5, k3]  ->  freq=2: [k1]  ->  freq=5: [k2, k4]
+ * 访问记录时，将其移动到下一个频率节点（不存在则创建），淘汰时取最小频率节点中最久未访问的记录
+ * 所有操作都是 O(1)
+ */
+
+type Cache struct {
+	// 允许使用的最大内存
+	maxBytes int64
+	// 当前已使用的内存
+	usedBytes int64
+	// 频率节点链表，front 的频率最小
+	freqs *list.List
+	// 缓存记录映射
+	cache map[string]*entry
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted policy.OnEvicted
+}
+
+// 同一访问次数的记录
+type freqNode struct {
+	freq int
+	// front 为最近访问
+	items *list.List
+}
+
+type entry struct {
+	key   string
+	value policy.Value
+	// 所在的频率节点
+	node *list.Element
+	// 在频率节点 items 中的位置
+	item *list.Element
+}
+
+// 实例化
+func New(maxBytes int64, onEvicted policy.OnEvicted) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		freqs:     list.New(),
+		cache:     make(map[string]*entry),
+		onEvicted: onEvicted,
+	}
+}
+
+// 查找
+func (c *Cache) Get(key string) (value policy.Value, ok bool) {
+	if e, ok := c.cache[key]; ok {
+		c.increment(e)
+		return e.value, true
+	}
+	return
+}
+
+// 添加/修改
+func (c *Cache) Set(key string, value policy.Value) {
+	if e, ok := c.cache[key]; ok {
+		c.usedBytes += int64(value.Len()) - int64(e.value.Len())
+		e.value = value
+		c.increment(e)
+	} else {
+		// 新记录的访问次数为 1
+		front := c.freqs.Front()
+		if front == nil || front.Value.(*freqNode).freq != 1 {
+			front = c.freqs.PushFront(&freqNode{freq: 1, items: list.New()})
+		}
+		e := &entry{key: key, value: value, node: front}
+		e.item = front.Value.(*freqNode).items.PushFront(e)
+		c.cache[key] = e
+		c.usedBytes += int64(value.Len()) + int64(len(key))
+	}
+
+	// 容量不足，淘汰访问次数最少的记录
+	for c.maxBytes != 0 && c.maxBytes < c.usedBytes {
+		c.RemoveLeastFrequent()
+	}
+}
+
+// 淘汰访问次数最少的记录
+func (c *Cache) RemoveLeastFrequent() {
+	if front := c.freqs.Front(); front != nil {
+		item := front.Value.(*freqNode).items.Back()
+		c.removeEntry(item.Value.(*entry))
+	}
+}
+
+// 删除指定的记录
+func (c *Cache) Remove(key string) {
+	if e, ok := c.cache[key]; ok {
+		c.removeEntry(e)
+	}
+}
+
+// 遍历所有记录，从访问次数最少的开始
+func (c *Cache) Range(fn func(key string, value policy.Value) bool) {
+	for node := c.freqs.Front(); node != nil; node = node.Next() {
+		for item := node.Value.(*freqNode).items.Back(); item != nil; item = item.Prev() {
+			e := item.Value.(*entry)
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// 缓存记录数量
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// 访问次数加一，移动到下一个频率节点
+func (c *Cache) increment(e *entry) {
+	current := e.node.Value.(*freqNode)
+	next := e.node.Next()
+	if next == nil || next.Value.(*freqNode).freq != current.freq+1 {
+		next = c.freqs.InsertAfter(&freqNode{freq: current.freq + 1, items: list.New()}, e.node)
+	}
+
+	current.items.Remove(e.item)
+	if current.items.Len() == 0 {
+		c.freqs.Remove(e.node)
+	}
+	e.node = next
+	e.item = next.Value.(*freqNode).items.PushFront(e)
+}
+
+func (c *Cache) removeEntry(e *entry) {
+	node := e.node.Value.(*freqNode)
+	node.items.Remove(e.item)
+	if node.items.Len() == 0 {
+		c.freqs.Remove(e.node)
+	}
+	delete(c.cache, e.key)
+
+	// 更新当前所用的内存
+	c.usedBytes -= int64(e.value.Len()) + int64(len(e.key))
+	// 执行回调
+	if c.onEvicted != nil {
+		c.onEvicted(e.key, e.value)
+	}
+}
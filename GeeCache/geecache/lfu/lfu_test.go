@@ -0,0 +1,69 @@
+package lfu
+
+import (
+	"geecache/geecache/policy"
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+func TestGet(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Set("key1", String("1234"))
+
+	if ele, ok := lfu.Get("key1"); !ok || string(ele.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := lfu.Get("key2"); ok {
+		t.Fatalf("cache hit key2 failed")
+	}
+}
+
+func TestRemoveLeastFrequent(t *testing.T) {
+	evicted := make([]string, 0)
+	lfu := New(int64(len("k1v1")*3), func(key string, value policy.Value) {
+		evicted = append(evicted, key)
+	})
+	lfu.Set("k1", String("v1"))
+	lfu.Set("k2", String("v2"))
+	lfu.Set("k3", String("v3"))
+
+	// k1 访问 3 次，k2 访问 1 次，k3 没有访问
+	lfu.Get("k1")
+	lfu.Get("k1")
+	lfu.Get("k2")
+	lfu.Get("k1")
+
+	lfu.Set("k4", String("v4"))
+	lfu.Set("k5", String("v5"))
+	// 次数相同时淘汰最久未访问的
+	if !reflect.DeepEqual(evicted, []string{"k3", "k4"}) {
+		t.Fatalf("expect k3 and k4 to be evicted, got %v", evicted)
+	}
+	if _, ok := lfu.Get("k1"); !ok || lfu.Len() != 3 {
+		t.Fatalf("frequent key k1 should be kept")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Set("k1", String("v1"))
+	lfu.Set("k2", String("v2"))
+	lfu.Get("k2")
+	lfu.Remove("k1")
+
+	keys := make([]string, 0)
+	lfu.Set("k3", String("v3"))
+	lfu.Range(func(key string, value policy.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []string{"k3", "k2"}) || lfu.usedBytes != int64(len("k2v2k3v3")) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
@@ -1,6 +1,9 @@
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"geecache/geecache/policy"
+)
 
 /* --------------------------------- LRU 算法 --------------------------------- */
 /**
@@ -30,10 +33,7 @@ type entry struct {
 }
 
 // 为了通用性，允许值是实现了 Value 接口的任意类型
-type Value interface {
-	// 返回值所占用的内存大小
-	Len() int
-}
+type Value = policy.Value
 
 // 实例化
 func New(maxBytes int64, onCallback callback) *Cache {
@@ -64,17 +64,38 @@ func (c *Cache) RemoveOldest() {
 	ele := c.list.Back()
 
 	if ele != nil {
-		// 删除队列中的元素
-		c.list.Remove(ele)
+		c.removeElement(ele)
+	}
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	// 删除队列中的元素
+	c.list.Remove(ele)
+	kv := ele.Value.(*entry)
+	// 删除缓存中的记录
+	delete(c.cache, kv.key)
+
+	// 更新当前所用的内存
+	c.usedBytes -= int64(kv.value.Len()) + int64(len(kv.key))
+	// 执行回调
+	if c.onCallback != nil {
+		c.onCallback(kv.key, kv.value)
+	}
+}
+
+// 删除指定的记录
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.removeElement(ele)
+	}
+}
+
+// 遍历所有记录，不改变访问顺序，fn 返回 false 时停止
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.list.Front(); ele != nil; ele = ele.Next() {
 		kv := ele.Value.(*entry)
-		// 删除缓存中的记录
-		delete(c.cache, kv.key)
-
-		// 更新当前所用的内存
-		c.usedBytes -= int64(kv.value.Len()) + int64(len(kv.key))
-		// 执行回调
-		if c.onCallback != nil {
-			c.onCallback(kv.key, kv.value)
+		if !fn(kv.key, kv.value) {
+			return
 		}
 	}
 }
@@ -55,3 +55,25 @@ func TestCallback(t *testing.T) {
 		t.Fatalf("call callback failed, keys: %s", keys)
 	}
 }
+
+func TestRemove(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Set("key1", String("1234"))
+	lru.Set("key2", String("5678"))
+	lru.Remove("key1")
+	lru.Remove("missing")
+
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 1 || lru.usedBytes != int64(len("key2")+len("5678")) {
+		t.Fatalf("remove key1 failed")
+	}
+
+	keys := make([]string, 0)
+	lru.Set("key3", String("9"))
+	lru.Range(func(key string, value Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if !reflect.DeepEqual(keys, []string{"key3", "key2"}) {
+		t.Fatalf("range should visit from the most recent, got %v", keys)
+	}
+}
@@ -0,0 +1,85 @@
+package geecache
+
+import (
+	"geecache/geecache/lfu"
+	"geecache/geecache/lru"
+	"geecache/geecache/policy"
+	"geecache/geecache/tinylfu"
+	"geecache/geecache/twoq"
+	"time"
+)
+
+/* ---------------------------------- Group 选项 --------------------------------- */
+/**
+ * NewGroup 的可选配置
+ *  geecache.NewGroup("scores", 2<<10, getter, geecache.WithTTL(time.Minute), geecache.WithPolicy(geecache.TinyLFU))
+ */
+
+const (
+	// hotCache 的内存为 mainCache 的 1/8
+	defaultHotRatio = 8
+	// 远程节点的结果每 10 个中保存一个到 hotCache
+	defaultHotSample = 10
+)
+
+type Option func(group *Group)
+
+// 设置默认的有效期，TTLGetter 返回的 ttl 为 0 时使用
+func WithTTL(ttl time.Duration) Option {
+	return func(group *Group) {
+		group.ttl = ttl
+	}
+}
+
+// 后台定期清理过期的记录，否则只在访问时删除
+// 清理的 goroutine 会一直运行，不再使用 Group 时必须调用 Close 停止
+func WithSweepInterval(interval time.Duration) Option {
+	return func(group *Group) {
+		group.sweepInterval = interval
+	}
+}
+
+// 设置 hotCache 的内存占 mainCache 的比例与抽样率，默认 1/8 与 10
+// 远程节点的结果每 sample 个中随机保存一个，ratio 或 sample 为 0 时不使用 hotCache
+//
+//	geecache.WithHotCache(0.25, 5)
+func WithHotCache(ratio float64, sample int) Option {
+	return func(group *Group) {
+		if ratio <= 0 || sample <= 0 {
+			group.hotSample = 0
+			return
+		}
+		group.hotCache.cacheBytes = int64(float64(group.mainCache.cacheBytes) * ratio)
+		group.hotSample = sample
+	}
+}
+
+// 设置淘汰策略，默认 LRU
+func WithPolicy(factory policy.Factory) Option {
+	return func(group *Group) {
+		group.mainCache.newPolicy = factory
+		group.hotCache.newPolicy = factory
+	}
+}
+
+/* ---------------------------------- 淘汰策略 --------------------------------- */
+
+// 淘汰最近最少访问的记录
+func LRU(maxBytes int64) policy.Policy {
+	return lru.New(maxBytes, nil)
+}
+
+// 淘汰访问次数最少的记录
+func LFU(maxBytes int64) policy.Policy {
+	return lfu.New(maxBytes, nil)
+}
+
+// 新记录再次访问才进入 LRU，抵抗扫描
+func TwoQ(maxBytes int64) policy.Policy {
+	return twoq.New(maxBytes, nil)
+}
+
+// 按估计的访问频率决定新记录能否进入缓存，抵抗扫描且适合长尾分布
+func TinyLFU(maxBytes int64) policy.Policy {
+	return tinylfu.New(maxBytes, nil)
+}
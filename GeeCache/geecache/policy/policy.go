@@ -0,0 +1,36 @@
+package policy
+
+/* --------------------------------- 淘汰策略 --------------------------------- */
+/**
+ * 缓存按占用的内存淘汰记录，不同的策略决定淘汰哪一条：
+ *  lru     淘汰最近最少访问的记录
+ *  lfu     淘汰访问次数最少的记录，次数相同时淘汰最久未访问的
+ *  twoq    新记录先进入 FIFO 队列，再次访问才进入 LRU 队列，一次性的扫描不会冲掉热点数据
+ *  tinylfu W-TinyLFU，通过 Count-Min Sketch 估计访问频率，只有比被淘汰者更热的记录才能进入主缓存
+ * 实现都不是并发安全的，由上层加锁
+ */
+
+// 为了通用性，允许值是实现了 Value 接口的任意类型
+type Value interface {
+	// 返回值所占用的内存大小
+	Len() int
+}
+
+// 某条记录被淘汰时的回调函数
+type OnEvicted func(key string, value Value)
+
+type Policy interface {
+	// 查找，同时记录一次访问
+	Get(key string) (value Value, ok bool)
+	// 添加/修改，内存不足时淘汰记录
+	Set(key string, value Value)
+	// 删除指定的记录
+	Remove(key string)
+	// 遍历所有记录，不记录访问，fn 返回 false 时停止
+	Range(fn func(key string, value Value) bool)
+	// 缓存记录数量
+	Len() int
+}
+
+// 根据允许使用的最大内存创建淘汰策略，0 表示不限制
+type Factory func(maxBytes int64) Policy
@@ -0,0 +1,125 @@
+package policy_test
+
+import (
+	"fmt"
+	"geecache/geecache/lfu"
+	"geecache/geecache/lru"
+	"geecache/geecache/policy"
+	"geecache/geecache/tinylfu"
+	"geecache/geecache/twoq"
+	"math/rand"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+const (
+	// 不同 key 的数量
+	keySpace = 10000
+	// 缓存可以容纳的记录数量
+	capacity = 500
+	// 每条记录占用的内存：6 字节的 key 与 10 字节的值
+	entrySize = 16
+	traceLen  = 200000
+)
+
+var policies = []struct {
+	name string
+	new  policy.Factory
+}{
+	{"LRU", func(maxBytes int64) policy.Policy { return lru.New(maxBytes, nil) }},
+	{"LFU", func(maxBytes int64) policy.Policy { return lfu.New(maxBytes, nil) }},
+	{"2Q", func(maxBytes int64) policy.Policy { return twoq.New(maxBytes, nil) }},
+	{"TinyLFU", func(maxBytes int64) policy.Policy { return tinylfu.New(maxBytes, nil) }},
+}
+
+// 服从 Zipf 分布的访问，少数 key 占据大部分访问
+func zipfTrace(seed int64) []string {
+	r := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(r, 1.01, 1, keySpace-1)
+
+	trace := make([]string, traceLen)
+	for i := range trace {
+		trace[i] = fmt.Sprintf("%06d", zipf.Uint64())
+	}
+	return trace
+}
+
+// 在 Zipf 访问中周期性插入一次性的顺序扫描
+func scanTrace(seed int64) []string {
+	trace := zipfTrace(seed)
+	next := 0
+	for i := 0; i+1000 <= len(trace); i += 2000 {
+		for j := i; j < i+1000; j++ {
+			trace[j] = fmt.Sprintf("s%05d", next%100000)
+			next++
+		}
+	}
+	return trace
+}
+
+// 回放访问序列，未命中时加入缓存，返回命中率
+func hitRatio(p policy.Policy, trace []string) float64 {
+	value := String("0123456789")
+	hits := 0
+	for _, key := range trace {
+		if _, ok := p.Get(key); ok {
+			hits++
+		} else {
+			p.Set(key, value)
+		}
+	}
+	return float64(hits) / float64(len(trace))
+}
+
+func TestHitRatio(t *testing.T) {
+	traces := map[string][]string{"zipf": zipfTrace(1), "scan": scanTrace(1)}
+	ratios := make(map[string]map[string]float64)
+
+	for traceName, trace := range traces {
+		ratios[traceName] = make(map[string]float64)
+		for _, p := range policies {
+			cache := p.new(capacity * entrySize)
+			ratios[traceName][p.name] = hitRatio(cache, trace)
+			if cache.Len() > capacity {
+				t.Fatalf("%s %s: expect at most %d entries, but got %d", traceName, p.name, capacity, cache.Len())
+			}
+			t.Logf("%s %-8s %.2f%%", traceName, p.name, ratios[traceName][p.name]*100)
+		}
+	}
+
+	// 扫描会冲掉 LRU 中的热点数据，2Q 与 TinyLFU 不受影响
+	scan := ratios["scan"]
+	if scan["2Q"] <= scan["LRU"] || scan["TinyLFU"] <= scan["LRU"] {
+		t.Fatalf("scan resistant policies should beat LRU on scan trace, got %v", scan)
+	}
+	if zipf := ratios["zipf"]; zipf["TinyLFU"] <= zipf["LRU"] {
+		t.Fatalf("TinyLFU should beat LRU on zipf trace, got %v", zipf)
+	}
+}
+
+func BenchmarkZipf(b *testing.B) {
+	benchmarkTrace(b, zipfTrace(1))
+}
+
+func BenchmarkScan(b *testing.B) {
+	benchmarkTrace(b, scanTrace(1))
+}
+
+// 每次迭代用新的缓存回放整个访问序列，并报告命中率
+func benchmarkTrace(b *testing.B, trace []string) {
+	for _, p := range policies {
+		b.Run(p.name, func(b *testing.B) {
+			var ratio float64
+			for i := 0; i < b.N; i++ {
+				ratio = hitRatio(p.new(capacity*entrySize), trace)
+			}
+			b.ReportMetric(ratio*100, "hit%")
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(trace)), "ns/access")
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package tinylfu
+
+import "hash/maphash"
+
+/* ------------------------------ Count-Min Sketch ------------------------------ */
+/**
+ * 用 depth 行计数器估计 key 的访问频率，每行使用不同的哈希位置，估计值取各行的最小值
+ * 计数器上限为 15(4 bit)，累计增加 sampleSize 次后所有计数器减半(老化)，使频率能反映近期的访问
+ */
+
+const (
+	depth = 4
+	// 计数器上限
+	maxCount = 15
+)
+
+type sketch struct {
+	seed maphash.Seed
+	rows [depth][]uint8
+	mask uint64
+	// 自上次老化以来的增加次数
+	additions int
+	// 达到该次数时老化
+	sampleSize int
+}
+
+// width 会被调整为 2 的幂
+func newSketch(width int) *sketch {
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+
+	s := &sketch{seed: maphash.MakeSeed(), mask: uint64(size - 1), sampleSize: size * 10}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, size)
+	}
+	return s
+}
+
+// 每行的位置由同一个 64 位哈希的高低两部分组合得到
+func (s *sketch) indexes(key string) [depth]uint64 {
+	hash := maphash.String(s.seed, key)
+	h1, h2 := hash&0xffffffff, hash>>32
+
+	var indexes [depth]uint64
+	for i := range indexes {
+		indexes[i] = (h1 + uint64(i)*h2) & s.mask
+	}
+	return indexes
+}
+
+// 记录一次访问
+func (s *sketch) increment(key string) {
+	added := false
+	for i, index := range s.indexes(key) {
+		if s.rows[i][index] < maxCount {
+			s.rows[i][index]++
+			added = true
+		}
+	}
+
+	if added {
+		s.additions++
+		if s.additions >= s.sampleSize {
+			s.reset()
+		}
+	}
+}
+
+// 估计访问频率
+func (s *sketch) estimate(key string) uint8 {
+	min := uint8(maxCount)
+	for i, index := range s.indexes(key) {
+		if count := s.rows[i][index]; count < min {
+			min = count
+		}
+	}
+	return min
+}
+
+// 所有计数器减半
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
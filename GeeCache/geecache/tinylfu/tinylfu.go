@@ -0,0 +1,227 @@
+package tinylfu
+
+import (
+	"container/list"
+	"geecache/geecache/policy"
+)
+
+/* ------------------------------- W-TinyLFU 算法 ------------------------------- */
+/**
+ * 缓存分为两部分：
+ *  window 1% 的内存，LRU，新记录先进入这里，使突发的新热点可以被缓存
+ *  main   99% 的内存，分段 LRU(SLRU)：
+ *           probation 20%，从 window 进入的记录
+ *           protected 80%，在 probation 中被再次访问的记录
+ * window 满时，被淘汰的记录作为候选者尝试进入 main，main 也满时与 main 中即将被淘汰的记录比较
+ * Count-Min Sketch 估计的访问频率，只有候选者更热时才淘汰 main 中的记录，否则淘汰候选者
+ * 扫描产生的大量一次性记录频率很低，无法进入 main，热点数据因此不会被冲掉
+ */
+
+const (
+	windowRatio    = 0.01
+	protectedRatio = 0.8
+	// 用于估计记录数量，以确定 sketch 的大小
+	averageEntrySize = 16
+)
+
+// 记录所在的区域
+const (
+	window = iota
+	probation
+	protected
+)
+
+type Cache struct {
+	// 允许使用的最大内存
+	maxBytes int64
+	// window、main、protected 允许使用的最大内存
+	maxWindow    int64
+	maxMain      int64
+	maxProtected int64
+	// 各区域已使用的内存
+	bytes [3]int64
+	// 各区域的 LRU 链表，front 为最近访问
+	lists [3]*list.List
+	// 缓存记录映射
+	cache map[string]*list.Element
+	// 访问频率
+	sketch *sketch
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted policy.OnEvicted
+}
+
+type entry struct {
+	key   string
+	value policy.Value
+	// 所在的区域
+	area int
+	// 占用的内存
+	size int64
+}
+
+// 实例化
+func New(maxBytes int64, onEvicted policy.OnEvicted) *Cache {
+	c := &Cache{
+		maxBytes:  maxBytes,
+		lists:     [3]*list.List{list.New(), list.New(), list.New()},
+		cache:     make(map[string]*list.Element),
+		onEvicted: onEvicted,
+	}
+
+	c.maxWindow = int64(float64(maxBytes) * windowRatio)
+	c.maxMain = maxBytes - c.maxWindow
+	c.maxProtected = int64(float64(c.maxMain) * protectedRatio)
+
+	// 不限制内存时使用固定大小的 sketch
+	width := 1 << 16
+	if maxBytes > 0 {
+		width = int(maxBytes / averageEntrySize)
+		if width < 1<<8 {
+			width = 1 << 8
+		} else if width > 1<<20 {
+			width = 1 << 20
+		}
+	}
+	c.sketch = newSketch(width)
+
+	return c
+}
+
+// 查找
+func (c *Cache) Get(key string) (value policy.Value, ok bool) {
+	// 未命中也记录访问，再次加入时才能判断它是否热门
+	c.sketch.increment(key)
+
+	ele, ok := c.cache[key]
+	if !ok {
+		return
+	}
+
+	e := ele.Value.(*entry)
+	switch e.area {
+	case probation:
+		// 再次访问，晋升到 protected
+		c.move(ele, protected)
+		c.demote()
+	default:
+		c.lists[e.area].MoveToFront(ele)
+	}
+	return e.value, true
+}
+
+// 添加/修改
+func (c *Cache) Set(key string, value policy.Value) {
+	size := int64(value.Len()) + int64(len(key))
+
+	if ele, ok := c.cache[key]; ok {
+		e := ele.Value.(*entry)
+		c.bytes[e.area] += size - e.size
+		e.value, e.size = value, size
+		c.lists[e.area].MoveToFront(ele)
+	} else {
+		e := &entry{key: key, value: value, size: size, area: window}
+		c.cache[key] = c.lists[window].PushFront(e)
+		c.bytes[window] += size
+	}
+
+	if c.maxBytes == 0 {
+		return
+	}
+
+	// window 中被淘汰的记录尝试进入 main
+	for c.bytes[window] > c.maxWindow {
+		c.admit(c.lists[window].Back())
+	}
+	c.demote()
+
+	// 修改导致记录变大时，按 probation、protected 的顺序淘汰
+	for c.maxBytes < c.bytes[window]+c.bytes[probation]+c.bytes[protected] {
+		c.evict(c.victim())
+	}
+}
+
+// 删除指定的记录
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.evict(ele)
+	}
+}
+
+// 遍历所有记录
+func (c *Cache) Range(fn func(key string, value policy.Value) bool) {
+	for _, area := range []int{protected, probation, window} {
+		for ele := c.lists[area].Front(); ele != nil; ele = ele.Next() {
+			e := ele.Value.(*entry)
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// 缓存记录数量
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// 候选者进入 main，main 已满时与被淘汰者比较访问频率
+func (c *Cache) admit(candidate *list.Element) {
+	e := candidate.Value.(*entry)
+	frequency := c.sketch.estimate(e.key)
+
+	for c.bytes[probation]+c.bytes[protected]+e.size > c.maxMain {
+		victim := c.mainVictim()
+		if victim == nil || frequency <= c.sketch.estimate(victim.Value.(*entry).key) {
+			// 候选者不够热，直接淘汰
+			c.evict(candidate)
+			return
+		}
+		c.evict(victim)
+	}
+
+	c.move(candidate, probation)
+}
+
+// protected 超出配额时，将最久未访问的记录降级到 probation
+func (c *Cache) demote() {
+	for c.bytes[protected] > c.maxProtected {
+		c.move(c.lists[protected].Back(), probation)
+	}
+}
+
+// main 中即将被淘汰的记录
+func (c *Cache) mainVictim() *list.Element {
+	if ele := c.lists[probation].Back(); ele != nil {
+		return ele
+	}
+	return c.lists[protected].Back()
+}
+
+// 内存不足时被淘汰的记录
+func (c *Cache) victim() *list.Element {
+	if ele := c.mainVictim(); ele != nil {
+		return ele
+	}
+	return c.lists[window].Back()
+}
+
+func (c *Cache) move(ele *list.Element, area int) {
+	e := ele.Value.(*entry)
+	c.lists[e.area].Remove(ele)
+	c.bytes[e.area] -= e.size
+
+	e.area = area
+	c.cache[e.key] = c.lists[area].PushFront(e)
+	c.bytes[area] += e.size
+}
+
+func (c *Cache) evict(ele *list.Element) {
+	e := ele.Value.(*entry)
+	c.lists[e.area].Remove(ele)
+	c.bytes[e.area] -= e.size
+	delete(c.cache, e.key)
+
+	if c.onEvicted != nil {
+		c.onEvicted(e.key, e.value)
+	}
+}
@@ -0,0 +1,95 @@
+package tinylfu
+
+import (
+	"fmt"
+	"geecache/geecache/policy"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+func TestGet(t *testing.T) {
+	cache := New(int64(0), nil)
+	cache.Set("key1", String("1234"))
+
+	if ele, ok := cache.Get("key1"); !ok || string(ele.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := cache.Get("key2"); ok {
+		t.Fatalf("cache hit key2 failed")
+	}
+}
+
+func TestSketch(t *testing.T) {
+	s := newSketch(100)
+	if len(s.rows[0]) != 128 {
+		t.Fatalf("width should be rounded up to a power of two")
+	}
+
+	for i := 0; i < 20; i++ {
+		s.increment("hot")
+	}
+	s.increment("cold")
+	if s.estimate("hot") != maxCount || s.estimate("cold") != 1 || s.estimate("missing") != 0 {
+		t.Fatalf("unexpected estimates hot=%d cold=%d", s.estimate("hot"), s.estimate("cold"))
+	}
+
+	s.reset()
+	if s.estimate("hot") != maxCount/2 || s.estimate("cold") != 0 {
+		t.Fatalf("counters should be halved after reset")
+	}
+}
+
+func TestAdmission(t *testing.T) {
+	evicted := make(map[string]bool)
+	cache := New(int64(len("k00v00")*100), func(key string, value policy.Value) {
+		evicted[key] = true
+	})
+
+	// 热点数据
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("k%02d", i)
+			if _, ok := cache.Get(key); !ok {
+				cache.Set(key, String("v00"))
+			}
+		}
+	}
+
+	// 一次性扫描的频率低，无法淘汰热点数据
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("s%03d", i)
+		if _, ok := cache.Get(key); !ok {
+			cache.Set(key, String("v00"))
+		}
+	}
+
+	// 最后加入的热点数据仍在 window 中，与 main 中的记录频率相同时不会被接纳
+	kept := 0
+	for i := 0; i < 50; i++ {
+		if !evicted[fmt.Sprintf("k%02d", i)] {
+			kept++
+		}
+	}
+	if kept < 49 {
+		t.Fatalf("hot keys should not be evicted by the scan, only %d kept", kept)
+	}
+	if cache.Len() > 100 {
+		t.Fatalf("expect at most 100 entries, but got %d", cache.Len())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	cache := New(int64(len("k00v00")*100), nil)
+	cache.Set("k1", String("v1"))
+	cache.Get("k1")
+	cache.Remove("k1")
+
+	if _, ok := cache.Get("k1"); ok || cache.Len() != 0 || cache.bytes != [3]int64{} {
+		t.Fatalf("remove k1 failed")
+	}
+}
@@ -0,0 +1,181 @@
+package twoq
+
+import (
+	"container/list"
+	"geecache/geecache/policy"
+)
+
+/* --------------------------------- 2Q 算法 --------------------------------- */
+/**
+ * 2Q 将缓存分为三个队列：
+ *  recent   FIFO，新加入的记录，超过最大内存的 25% 时优先淘汰这里的记录
+ *  frequent LRU，被再次访问过的记录
+ *  ghost    FIFO，只保存从 recent 中淘汰的 key，不占用缓存内存
+ * recent 中的记录再次被访问，或被淘汰的 key 在 ghost 中时再次加入，说明它不是一次性的访问，进入 frequent
+ * 一次性的扫描只会经过 recent，不会冲掉 frequent 中的热点数据
+ */
+
+const (
+	// recent 占最大内存的比例
+	recentRatio = 0.25
+	// ghost 按记录大小计算的容量占最大内存的比例
+	ghostRatio = 0.5
+)
+
+// 记录所在的队列
+const (
+	recent = iota
+	frequent
+	ghost
+)
+
+type Cache struct {
+	// 允许使用的最大内存
+	maxBytes int64
+	// 各队列的内存，ghost 为被淘汰记录原本的大小
+	bytes [3]int64
+	// 三个队列，front 为最近加入/访问
+	lists [3]*list.List
+	// 缓存记录映射，包括 ghost 中的 key
+	cache map[string]*list.Element
+	// 某条记录被移除时的回调函数，可以为 nil
+	onEvicted policy.OnEvicted
+}
+
+type entry struct {
+	key   string
+	value policy.Value
+	// 所在的队列
+	queue int
+	// 占用的内存
+	size int64
+}
+
+// 实例化
+func New(maxBytes int64, onEvicted policy.OnEvicted) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		lists:     [3]*list.List{list.New(), list.New(), list.New()},
+		cache:     make(map[string]*list.Element),
+		onEvicted: onEvicted,
+	}
+}
+
+// 查找
+func (c *Cache) Get(key string) (value policy.Value, ok bool) {
+	ele, ok := c.cache[key]
+	if !ok {
+		return
+	}
+
+	e := ele.Value.(*entry)
+	switch e.queue {
+	case recent:
+		// 再次访问，进入 frequent
+		c.remove(ele)
+		c.push(frequent, e)
+	case frequent:
+		c.lists[frequent].MoveToFront(ele)
+	case ghost:
+		return nil, false
+	}
+	return e.value, true
+}
+
+// 添加/修改
+func (c *Cache) Set(key string, value policy.Value) {
+	size := int64(value.Len()) + int64(len(key))
+
+	if ele, ok := c.cache[key]; ok {
+		e := ele.Value.(*entry)
+		switch e.queue {
+		case ghost:
+			// 被淘汰后再次加入，进入 frequent
+			c.remove(ele)
+			c.push(frequent, &entry{key: key, value: value, size: size})
+		default:
+			c.bytes[e.queue] += size - e.size
+			e.value, e.size = value, size
+			if e.queue == frequent {
+				c.lists[frequent].MoveToFront(ele)
+			}
+		}
+	} else {
+		c.push(recent, &entry{key: key, value: value, size: size})
+	}
+
+	c.evict()
+}
+
+// 删除指定的记录
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		e := ele.Value.(*entry)
+		c.remove(ele)
+		if e.queue != ghost && c.onEvicted != nil {
+			c.onEvicted(e.key, e.value)
+		}
+	}
+}
+
+// 遍历所有记录，先 frequent 后 recent
+func (c *Cache) Range(fn func(key string, value policy.Value) bool) {
+	for _, queue := range []int{frequent, recent} {
+		for ele := c.lists[queue].Front(); ele != nil; ele = ele.Next() {
+			e := ele.Value.(*entry)
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// 缓存记录数量，不包括 ghost
+func (c *Cache) Len() int {
+	return c.lists[recent].Len() + c.lists[frequent].Len()
+}
+
+// 容量不足时，recent 超出配额则淘汰 recent，否则淘汰 frequent
+func (c *Cache) evict() {
+	if c.maxBytes == 0 {
+		return
+	}
+
+	for c.maxBytes < c.bytes[recent]+c.bytes[frequent] {
+		recentLimit := int64(float64(c.maxBytes) * recentRatio)
+		if c.lists[recent].Len() > 0 && (c.bytes[recent] > recentLimit || c.lists[frequent].Len() == 0) {
+			ele := c.lists[recent].Back()
+			e := ele.Value.(*entry)
+			c.remove(ele)
+			c.push(ghost, &entry{key: e.key, size: e.size})
+			if c.onEvicted != nil {
+				c.onEvicted(e.key, e.value)
+			}
+		} else {
+			ele := c.lists[frequent].Back()
+			e := ele.Value.(*entry)
+			c.remove(ele)
+			if c.onEvicted != nil {
+				c.onEvicted(e.key, e.value)
+			}
+		}
+	}
+
+	ghostLimit := int64(float64(c.maxBytes) * ghostRatio)
+	for c.bytes[ghost] > ghostLimit {
+		c.remove(c.lists[ghost].Back())
+	}
+}
+
+func (c *Cache) push(queue int, e *entry) {
+	e.queue = queue
+	c.cache[e.key] = c.lists[queue].PushFront(e)
+	c.bytes[queue] += e.size
+}
+
+func (c *Cache) remove(ele *list.Element) {
+	e := ele.Value.(*entry)
+	c.lists[e.queue].Remove(ele)
+	c.bytes[e.queue] -= e.size
+	delete(c.cache, e.key)
+}
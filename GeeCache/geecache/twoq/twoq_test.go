@@ -0,0 +1,77 @@
+package twoq
+
+import (
+	"fmt"
+	"geecache/geecache/policy"
+	"testing"
+)
+
+type String string
+
+func (s String) Len() int {
+	return len(s)
+}
+
+func TestGet(t *testing.T) {
+	cache := New(int64(0), nil)
+	cache.Set("key1", String("1234"))
+
+	if ele, ok := cache.Get("key1"); !ok || string(ele.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := cache.Get("key2"); ok {
+		t.Fatalf("cache hit key2 failed")
+	}
+}
+
+func TestScanResistant(t *testing.T) {
+	// 可以容纳 10 条记录
+	cache := New(int64(len("k00v00")*10), nil)
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("k%02d", i)
+		cache.Set(key, String("v00"))
+		// 再次访问进入 frequent
+		cache.Get(key)
+	}
+
+	// 一次性扫描
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("s%02d", i), String("v00"))
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, ok := cache.Get(fmt.Sprintf("k%02d", i)); !ok {
+			t.Fatalf("hot key k%02d should survive the scan", i)
+		}
+	}
+	if cache.Len() != 10 {
+		t.Fatalf("expect 10 entries, but got %d", cache.Len())
+	}
+}
+
+func TestGhost(t *testing.T) {
+	evicted := make([]string, 0)
+	cache := New(int64(len("k00v00")*4), func(key string, value policy.Value) {
+		evicted = append(evicted, key)
+	})
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("k%02d", i), String("v00"))
+	}
+	if len(evicted) != 1 || evicted[0] != "k00" {
+		t.Fatalf("expect k00 to be evicted, got %v", evicted)
+	}
+
+	// 被淘汰的 key 再次加入时直接进入 frequent
+	if _, ok := cache.Get("k00"); ok {
+		t.Fatalf("ghost key should not be hit")
+	}
+	cache.Set("k00", String("v00"))
+	if e := cache.cache["k00"].Value.(*entry); e.queue != frequent {
+		t.Fatalf("ghost key should be promoted to frequent")
+	}
+
+	cache.Remove("k00")
+	if _, ok := cache.Get("k00"); ok || cache.Len() != 3 {
+		t.Fatalf("remove k00 failed")
+	}
+}